@@ -26,6 +26,7 @@ import (
 	"crypto/rsa"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -861,3 +862,18 @@ func GetToken(c *gin.Context) string {
 
 	return token.(string)
 }
+
+// GetIdentity 返回 JWT 中间件写入的身份标识，未认证时返回空字符串。
+func GetIdentity(c *gin.Context, identityKey ...string) string {
+	key := IdentityKey
+	if len(identityKey) > 0 && identityKey[0] != "" {
+		key = identityKey[0]
+	}
+	if identity, exists := c.Get(key); exists && identity != nil {
+		return fmt.Sprint(identity)
+	}
+	if identity, ok := ExtractClaims(c)[key]; ok && identity != nil {
+		return fmt.Sprint(identity)
+	}
+	return ""
+}
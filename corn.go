@@ -2,6 +2,7 @@ package gb
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
@@ -16,6 +17,7 @@ type CornConfig struct {
 	afterJobRuns          func(jobID uuid.UUID, jobName string)            // 运行后
 	afterJobRunsWithError func(jobID uuid.UUID, jobName string, err error) // 出错
 	options               []gocron.SchedulerOption
+	running               atomic.Bool // 调度器是否已启动
 	Scheduler             gocron.Scheduler
 }
 
@@ -215,13 +217,17 @@ func InitCornJob(options ...CornOptionFunc) error {
 			gocron.WithEventListeners(eventListeners...),
 		))
 	}
+	// 任务指标由 gocron 按每次执行上报开始与结束时间，同一任务重叠执行时互不影响；
+	// 放在用户选项之前，通过 WithCornJobs 传入的 WithMonitorStatus 会覆盖它
+	corn.options = append([]gocron.SchedulerOption{gocron.WithMonitorStatus(cronMetricsMonitor{})}, corn.options...)
 
 	scheduler, err := gocron.NewScheduler(corn.options...)
 	if err != nil {
 		return err
 	}
 
-	corn.Scheduler = scheduler
+	// 包装调度器，直接调用 Scheduler.Start() 时也能记录运行状态
+	corn.Scheduler = &cornScheduler{Scheduler: scheduler, running: &corn.running}
 	InsCornJob = corn
 	return nil
 }
@@ -238,3 +244,32 @@ func (corn *CornConfig) Stop() error {
 	}
 	return nil
 }
+
+// Running 返回调度器是否处于运行状态。
+func (corn *CornConfig) Running() bool {
+	return corn.running.Load()
+}
+
+// cornScheduler 在启动、停止时同步 CornConfig 的运行状态。
+type cornScheduler struct {
+	gocron.Scheduler
+	running *atomic.Bool
+}
+
+// Start 方法用于处理Start相关逻辑。
+func (s *cornScheduler) Start() {
+	s.Scheduler.Start()
+	s.running.Store(true)
+}
+
+// StopJobs 方法用于处理StopJobs相关逻辑。
+func (s *cornScheduler) StopJobs() error {
+	s.running.Store(false)
+	return s.Scheduler.StopJobs()
+}
+
+// Shutdown 方法用于处理Shutdown相关逻辑。
+func (s *cornScheduler) Shutdown() error {
+	s.running.Store(false)
+	return s.Scheduler.Shutdown()
+}
@@ -0,0 +1,29 @@
+package gb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHealthCheckCornJobWithDirectSchedulerStart(t *testing.T) {
+	if err := InitCornJob(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { InsCornJob = nil }()
+	check := HealthCheckCornJob()
+	if err := check(context.Background()); err == nil {
+		t.Fatal("health check passed before the scheduler started")
+	}
+
+	InsCornJob.Scheduler.Start()
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("health check failed after Scheduler.Start(): %v", err)
+	}
+
+	if err := InsCornJob.Scheduler.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(context.Background()); err == nil {
+		t.Fatal("health check passed after Scheduler.Shutdown()")
+	}
+}
@@ -0,0 +1,124 @@
+package gb
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentDisposition 生成 Content-Disposition，filename 为 ASCII 兜底名，filename* 按 RFC 5987 使用 UTF-8 编码以支持中文文件名。
+func ContentDisposition(disposition, filename string) string {
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	if filename == "" {
+		return disposition
+	}
+	var fallback strings.Builder
+	for _, ch := range filename {
+		switch {
+		case ch == '"' || ch == '\\' || ch == '/' || ch < 0x20 || ch == 0x7f:
+			fallback.WriteByte('_')
+		case ch > 0x7e:
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(ch)
+		}
+	}
+	return disposition + `; filename="` + fallback.String() + `"; filename*=UTF-8''` + rfc5987Escape(filename)
+}
+
+// rfc5987Escape 只保留 RFC 5987 attr-char，其余字节按 UTF-8 百分号编码。
+func rfc5987Escape(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&0x0f])
+	}
+	return b.String()
+}
+
+// ResponseFile 以流的方式返回文件，支持 Range、If-Modified-Since 等条件请求。
+// disposition 默认为 attachment，在页面中直接展示（如 <img>、PDF 预览）时传 inline。
+func ResponseFile(c *gin.Context, content io.ReadSeeker, filename string, modTime time.Time, disposition ...string) {
+	d := "attachment"
+	if len(disposition) > 0 && disposition[0] != "" {
+		d = disposition[0]
+	}
+	c.Header("Content-Disposition", ContentDisposition(d, filename))
+	// 禁止浏览器根据内容猜测类型，避免 inline 返回的上传文件被当作 HTML 执行
+	c.Header("X-Content-Type-Options", "nosniff")
+	if c.Writer.Header().Get("Content-Type") == "" {
+		if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+			c.Header("Content-Type", contentType)
+		}
+	}
+	setTraceHeaders(c)
+	c.Set("resp-status", http.StatusOK)
+	http.ServeContent(c.Writer, c.Request, filename, modTime, content)
+}
+
+// ResponseStorageFile 从 Storage 读取并返回文件，本地存储支持 Range，其他存储按完整内容流式输出。
+// filename 为空时使用 key 的文件名。
+func ResponseStorageFile(c *gin.Context, storage Storage, key, filename string, disposition ...string) {
+	reader, object, err := storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			ResponseError(c, ErrNotFound)
+		} else {
+			WriteGinWarnLog(c, "storage get %s failed: %v", key, err)
+			ResponseError(c, ErrServerBusy)
+		}
+		return
+	}
+	defer reader.Close()
+	if filename == "" {
+		filename = path.Base(key)
+	}
+	if object.ContentType != "" {
+		c.Header("Content-Type", object.ContentType)
+	}
+	if object.ETag != "" {
+		c.Header("ETag", strconv.Quote(object.ETag))
+	}
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		ResponseFile(c, seeker, filename, object.LastModified, disposition...)
+		return
+	}
+
+	d := "attachment"
+	if len(disposition) > 0 && disposition[0] != "" {
+		d = disposition[0]
+	}
+	c.Header("Content-Disposition", ContentDisposition(d, filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	setTraceHeaders(c)
+	c.Set("resp-status", http.StatusOK)
+	if object.Size >= 0 {
+		c.Header("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	// 响应头已发出，出错时只能记录日志，客户端会收到不完整的内容
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		WriteGinWarnLog(c, "storage copy %s failed: %v", key, err)
+	}
+}
@@ -0,0 +1,88 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func TestContentDispositionChineseFilename(t *testing.T) {
+	got := ContentDisposition("", `报告 "2024".pdf`)
+	want := `attachment; filename="__ _2024_.pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%20%222024%22.pdf`
+	if got != want {
+		t.Fatalf("ContentDisposition = %s, want %s", got, want)
+	}
+	if got := ContentDisposition("inline", ""); got != "inline" {
+		t.Fatalf("ContentDisposition without filename = %s", got)
+	}
+}
+
+func TestResponseFileRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/download", func(c *gin.Context) {
+		ResponseFile(c, strings.NewReader("0123456789"), "报告.txt", time.Now())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" {
+		t.Fatalf("range response = %d %q, want 206 \"2345\"", w.Code, w.Body.String())
+	}
+	header := w.Header()
+	if header.Get("Content-Range") != "bytes 2-5/10" || header.Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("range headers = %v", header)
+	}
+	if !strings.HasPrefix(header.Get("Content-Type"), "text/plain") || header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("content headers = %v", header)
+	}
+	if !strings.Contains(header.Get("Content-Disposition"), "filename*=UTF-8''%E6%8A%A5%E5%91%8A.txt") {
+		t.Fatalf("Content-Disposition = %s", header.Get("Content-Disposition"))
+	}
+}
+
+// failingStorage 返回不可 Seek 且读取中途出错的对象，模拟远端存储连接中断。
+type failingStorage struct {
+	Storage
+}
+
+func (failingStorage) Get(context.Context, string) (io.ReadCloser, StorageObject, error) {
+	reader := io.MultiReader(strings.NewReader("partial"), &errorReader{err: errors.New("connection reset")})
+	return io.NopCloser(reader), StorageObject{Size: 100}, nil
+}
+
+type errorReader struct{ err error }
+
+func (r *errorReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestResponseStorageFileLogsCopyError(t *testing.T) {
+	requestLogger := NewRequestLogger(context.Background(), zerolog.Nop())
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/download", func(c *gin.Context) {
+		c.Set(string(RequestLoggerKey), requestLogger)
+		ResponseStorageFile(c, failingStorage{}, "docs/a.bin", "")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/download", nil))
+	if w.Body.String() != "partial" {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+	for _, entry := range requestLogger.entries {
+		if entry.Level == zerolog.WarnLevel && strings.Contains(entry.Message, "connection reset") {
+			return
+		}
+	}
+	t.Fatalf("copy error not logged, entries = %+v", requestLogger.entries)
+}
@@ -6,18 +6,35 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"time"
 )
 
 type EncryptedResponse struct {
-	Data      string `json:"data"`      // 加密的数据
-	Timestamp int64  `json:"timestamp"` // 时间戳
-	Nonce     string `json:"nonce"`     // 随机数，增加安全性
+	Data      string `json:"data"`          // 加密的数据
+	Timestamp int64  `json:"timestamp"`     // 时间戳
+	Nonce     string `json:"nonce"`         // 随机数，增加安全性
+	Kid       string `json:"kid,omitempty"` // 密钥编号，使用 KeyRing 加密时写入
 }
 
-// encryptAESGCM 函数用于处理encryptAESGCM相关逻辑。
-func encryptAESGCM(plaintext []byte, key []byte) (string, error) {
+// validateAESKey AES 密钥长度只能是 16、24 或 32 字节。
+func validateAESKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("AES密钥长度必须为16、24或32字节,当前为%d字节", len(key))
+	}
+}
+
+// encryptAESGCM 加密 plaintext，aad 为附加认证数据，不加密但参与认证，解密时需传入相同的值。
+func encryptAESGCM(plaintext []byte, key []byte, aad []byte) (string, error) {
+	if err := validateAESKey(key); err != nil {
+		return "", err
+	}
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
@@ -33,10 +50,37 @@ func encryptAESGCM(plaintext []byte, key []byte) (string, error) {
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, aad)
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// decryptAESGCM 函数用于处理decryptAESGCM相关逻辑。
+func decryptAESGCM(ciphertext string, key []byte, aad []byte) ([]byte, error) {
+	if err := validateAESKey(key); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, aad)
+}
+
 // generateNonce 函数用于处理generateNonce相关逻辑。
 func generateNonce(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -46,7 +90,12 @@ func generateNonce(length int) (string, error) {
 	return base64.StdEncoding.EncodeToString(bytes), nil
 }
 
-// EncryptData 函数用于处理EncryptData相关逻辑。
+// timestampAAD 将时间戳作为 GCM 附加认证数据，防止重放时改写时间戳绕过时间窗口校验。
+func timestampAAD(timestamp int64) []byte {
+	return []byte(strconv.FormatInt(timestamp, 10))
+}
+
+// EncryptData 使用 custom(now) 返回的密钥加密 data，时间戳参与认证（见 timestampAAD）。
 func EncryptData(data any, custom func(now int64) (key, nonce string)) (*EncryptedResponse, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -56,8 +105,7 @@ func EncryptData(data any, custom func(now int64) (key, nonce string)) (*Encrypt
 	now := time.Now().Unix()
 	key, nonce := custom(now)
 
-	// 加密数据
-	encryptedData, err := encryptAESGCM(jsonData, []byte(key))
+	encryptedData, err := encryptAESGCM(jsonData, []byte(key), timestampAAD(now))
 	if err != nil {
 		return nil, err
 	}
@@ -68,3 +116,21 @@ func EncryptData(data any, custom func(now int64) (key, nonce string)) (*Encrypt
 		Nonce:     nonce,
 	}, nil
 }
+
+// DecryptDataBytes 使用 custom(timestamp) 返回的密钥解密 EncryptedResponse，返回明文 JSON；时间戳被改动时解密失败。
+func DecryptDataBytes(encrypted *EncryptedResponse, custom func(now int64) (key, nonce string)) ([]byte, error) {
+	if encrypted == nil {
+		return nil, errors.New("encrypted data is nil")
+	}
+	key, _ := custom(encrypted.Timestamp)
+	return decryptAESGCM(encrypted.Data, []byte(key), timestampAAD(encrypted.Timestamp))
+}
+
+// DecryptData 是 EncryptData 的逆操作，解密后反序列化到 v。
+func DecryptData(encrypted *EncryptedResponse, custom func(now int64) (key, nonce string), v any) error {
+	plaintext, err := DecryptDataBytes(encrypted, custom)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, v)
+}
@@ -154,7 +154,7 @@ func (db *GormClient) Gen(opts ...WithGenConfig) {
 	}
 
 	g := gen.NewGenerator(gen.Config{
-		OutPath:        fmt.Sprintf(genConfig.outFilePath),
+		OutPath:        genConfig.outFilePath,
 		FieldCoverable: false,
 		Mode:           gen.WithDefaultQuery | gen.WithQueryInterface | gen.WithoutContext,
 	})
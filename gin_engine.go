@@ -1,14 +1,23 @@
 package gb
 
 import (
+	"crypto/x509"
+	"fmt"
+	"path"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	PublicRoutes  = make([]func(*gin.RouterGroup), 0) // 存储无需认证的公开路由处理函数
-	PrivateRoutes = make([]func(*gin.RouterGroup), 0) // 存储需要认证的私有路由处理函数
+	// PublicRoutes 存储无需认证的公开路由处理函数，注册在默认路由（WithGinRouterPrefix）下。
+	//
+	// Deprecated: 使用 NewRouter 构建实例级路由，并通过 WithGinRouters 注册。
+	PublicRoutes = make([]func(*gin.RouterGroup), 0)
+	// PrivateRoutes 存储需要认证的私有路由处理函数，注册在默认路由（WithGinRouterPrefix）下。
+	//
+	// Deprecated: 使用 NewRouter 构建实例级路由，并通过 WithGinRouters 注册。
+	PrivateRoutes = make([]func(*gin.RouterGroup), 0)
 )
 
 type RouterConfig struct {
@@ -16,6 +25,11 @@ type RouterConfig struct {
 	model            GinModel          // gin启动模式
 	prefix           string            // api前缀
 	authMiddleware   []gin.HandlerFunc // 认证api的中间件
+	routers          []*Router         // 实例级路由
+	health           *Health           // /livez 与 /readyz 的健康检查
+	metricsPath      string            // Prometheus 指标接口路径
+	routeTablePath   string            // 路由表调试接口路径
+	routeTableAuth   []gin.HandlerFunc // 路由表调试接口的处理器（如认证）
 	globalMiddleware []gin.HandlerFunc // 全局中间件
 	recordHeaderKeys []string          // 需要记录的请求头
 	saveLog          func(ReqLog)      // 保存请求日志
@@ -23,7 +37,19 @@ type RouterConfig struct {
 	writeTimeout     time.Duration
 	idleTimeout      time.Duration
 	maxHeaderBytes   int
+	shutdownTimeout  time.Duration // 优雅关闭的超时时间
 	skipLog          bool
+	tlsCertFile      string         // https 证书文件
+	tlsKeyFile       string         // https 私钥文件
+	tlsClientCAFiles []string       // 双向认证的客户端 CA 文件
+	tlsClientCAs     *x509.CertPool // 双向认证的客户端 CA 池
+	tlsMinVersion    uint16         // 最低 TLS 版本
+	h2c              bool           // 是否开启明文 HTTP/2
+	trustedProxies   []string       // 可信代理的 IP 或 CIDR，默认不信任任何代理
+	remoteIPHeaders  []string       // 从可信代理读取客户端 IP 的请求头
+	trustedPlatform  string         // 可信平台请求头，如 gin.PlatformCloudflare
+	recovery         RecoveryConfig // panic 恢复配置
+	maintenance      *Maintenance   // 维护模式开关
 }
 
 type GinModel string
@@ -76,6 +102,13 @@ func WithGinMaxHeaderBytes(d int) GinRouterConfigOptionFunc {
 	}
 }
 
+// WithGinShutdownTimeout 设置优雅关闭时等待进行中请求的超时时间，默认 10 秒。
+func WithGinShutdownTimeout(d time.Duration) GinRouterConfigOptionFunc {
+	return func(routerConfig *RouterConfig) {
+		routerConfig.shutdownTimeout = d
+	}
+}
+
 // WithGinRouterModel 函数用于处理WithGinRouterModel相关逻辑。
 func WithGinRouterModel(model GinModel) GinRouterConfigOptionFunc {
 	return func(config *RouterConfig) {
@@ -97,13 +130,79 @@ func WithGinRouterPrefix(prefix string) GinRouterConfigOptionFunc {
 	}
 }
 
-// WithGinRouterAuthHandler 函数用于处理WithGinRouterAuthHandler相关逻辑。
+// WithGinRouterAuthHandler 设置默认路由（PublicRoutes/PrivateRoutes）私有路由的认证处理器，
+// 通过 WithGinRouters 注册的路由使用各自分组的 Auth。
 func WithGinRouterAuthHandler(handlers ...gin.HandlerFunc) GinRouterConfigOptionFunc {
 	return func(config *RouterConfig) {
 		config.authMiddleware = handlers
 	}
 }
 
+// WithGinRouters 注册实例级路由，可与默认路由共存。
+func WithGinRouters(routers ...*Router) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.routers = append(config.routers, routers...)
+	}
+}
+
+// WithGinRouterHealth 设置 /livez 与 /readyz 使用的健康检查注册表。
+func WithGinRouterHealth(health *Health) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.health = health
+	}
+}
+
+// WithGinRouterMetrics 开启请求指标采集，并在 path（如 /metrics）挂载 Prometheus 指标接口。
+func WithGinRouterMetrics(path string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.metricsPath = path
+	}
+}
+
+// WithGinRouterRouteTable 开启路由表调试接口，以 JSON 返回全部路由及其公开/私有标记、处理函数和中间件链。
+func WithGinRouterRouteTable(path string, handlers ...gin.HandlerFunc) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.routeTablePath = path
+		config.routeTableAuth = handlers
+	}
+}
+
+// WithGinTrustedProxies 设置可信代理的 IP 或 CIDR，只有来自这些地址的请求才会读取 X-Forwarded-For 等请求头。
+// 未设置时不信任任何代理，c.ClientIP() 直接返回连接的远端地址。
+func WithGinTrustedProxies(proxies ...string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.trustedProxies = append(config.trustedProxies, proxies...)
+	}
+}
+
+// WithGinRemoteIPHeaders 设置从可信代理读取客户端 IP 的请求头，默认为 X-Forwarded-For、X-Real-IP。
+func WithGinRemoteIPHeaders(headers ...string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.remoteIPHeaders = headers
+	}
+}
+
+// WithGinTrustedPlatform 设置可信平台请求头（如 gin.PlatformCloudflare、gin.PlatformGoogleAppEngine），优先于代理请求头。
+func WithGinTrustedPlatform(platform string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.trustedPlatform = platform
+	}
+}
+
+// WithGinRecovery 设置 panic 恢复配置，如隐藏详情、上报钩子与 HTTP 状态码。
+func WithGinRecovery(recovery RecoveryConfig) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.recovery = recovery
+	}
+}
+
+// WithGinMaintenance 挂载维护模式中间件，该服务的 /healthz、/livez、/readyz 在维护期间始终放行。
+func WithGinMaintenance(maintenance *Maintenance) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.maintenance = maintenance
+	}
+}
+
 // WithGinRouterGlobalMiddleware 函数用于处理WithGinRouterGlobalMiddleware相关逻辑。
 func WithGinRouterGlobalMiddleware(handlers ...gin.HandlerFunc) GinRouterConfigOptionFunc {
 	return func(config *RouterConfig) {
@@ -126,34 +225,75 @@ func WithGinRouterLogSaveLog(f func(ReqLog)) GinRouterConfigOptionFunc {
 }
 
 // initPrivateRouter 函数用于处理initPrivateRouter相关逻辑。
-func initPrivateRouter(config RouterConfig) *gin.Engine {
-	publicRoutes := make([]func(*gin.RouterGroup), 0, len(PublicRoutes)+1)
-	publicRoutes = append(publicRoutes, func(group *gin.RouterGroup) {
-		if !config.outputHealthz {
-			group.Any("/healthz", GinLogSetSkipLogFlag(), func(c *gin.Context) {
-				c.Status(200)
-			})
-		} else {
-			group.Any("/healthz", func(c *gin.Context) {
-				c.Status(200)
-			})
+func initPrivateRouter(config RouterConfig) (*gin.Engine, []RouteTableEntry, error) {
+	defaultRouter := NewRouter(config.prefix)
+	defaultRouter.Auth(config.authMiddleware...)
+	health := config.health
+	if health == nil {
+		health = NewHealth()
+	}
+	defaultRouter.Public(func(group *gin.RouterGroup) {
+		probe := func(handler gin.HandlerFunc) []gin.HandlerFunc {
+			if !config.outputHealthz {
+				return []gin.HandlerFunc{GinLogSetSkipLogFlag(), handler}
+			}
+			return []gin.HandlerFunc{handler}
 		}
+		group.Any("/healthz", probe(func(c *gin.Context) {
+			c.Status(200)
+		})...)
+		group.GET("/livez", probe(health.LivenessHandler())...)
+		group.GET("/readyz", probe(health.ReadinessHandler())...)
 	})
-	publicRoutes = append(publicRoutes, PublicRoutes...)
-
-	privateRoutes := append(make([]func(*gin.RouterGroup), 0, len(PrivateRoutes)), PrivateRoutes...)
+	defaultRouter.Public(PublicRoutes...)
+	defaultRouter.Private(PrivateRoutes...)
 
-	config.globalMiddleware = append(config.globalMiddleware, MiddlewareTraceID(), MiddlewareRequestTime(), MiddlewareRecovery())
+	config.globalMiddleware = append(config.globalMiddleware, MiddlewareTraceID(), MiddlewareRequestTime())
+	if config.metricsPath != "" {
+		config.globalMiddleware = append(config.globalMiddleware, MiddlewareMetrics())
+	}
+	config.globalMiddleware = append(config.globalMiddleware, MiddlewareRecoveryWithConfig(config.recovery))
 	if !config.skipLog {
 		config.globalMiddleware = append(config.globalMiddleware, MiddlewareLogger(MiddlewareLogConfig{
 			HeaderKeys: config.recordHeaderKeys,
 			SaveLog:    config.saveLog,
 		}))
 	}
+	if config.maintenance != nil {
+		probes := make([]string, 0, 3)
+		for _, name := range []string{"/healthz", "/livez", "/readyz"} {
+			probes = append(probes, path.Join("/", config.prefix, name))
+		}
+		config.globalMiddleware = append(config.globalMiddleware, config.maintenance.middleware(probes))
+	}
 
 	engine := newGinRouter(config.model, config.globalMiddleware...)
-	registerRoutes(engine, config.prefix, publicRoutes, privateRoutes, config.authMiddleware...)
-	return engine
+	if err := engine.SetTrustedProxies(config.trustedProxies); err != nil {
+		return nil, nil, fmt.Errorf("可信代理配置错误: %w", err)
+	}
+	if len(config.remoteIPHeaders) > 0 {
+		engine.RemoteIPHeaders = config.remoteIPHeaders
+	}
+	engine.TrustedPlatform = config.trustedPlatform
+	routes := defaultRouter.Register(engine)
+	for _, router := range config.routers {
+		routes = append(routes, router.Register(engine)...)
+	}
+
+	if config.metricsPath != "" {
+		routes = append(routes, recordEngineRoute(engine, &engine.RouterGroup, true, func() {
+			engine.GET(config.metricsPath, GinLogSetSkipLogFlag(), MetricsHandler())
+		})...)
+	}
+	if config.routeTablePath != "" {
+		handlers := append(append([]gin.HandlerFunc{}, config.routeTableAuth...), func(c *gin.Context) {
+			ResponseSuccess(c, BuildRouteTable(engine, routes))
+		})
+		routes = append(routes, recordEngineRoute(engine, &engine.RouterGroup, len(config.routeTableAuth) == 0, func() {
+			engine.GET(config.routeTablePath, handlers...)
+		})...)
+	}
+	return engine, routes, nil
 }
 
 // newGinRouter 函数用于处理newGinRouter相关逻辑。
@@ -166,16 +306,3 @@ func newGinRouter(mode GinModel, globalMiddlewares ...gin.HandlerFunc) *gin.Engi
 
 	return engine
 }
-
-// registerRoutes 函数用于处理registerRoutes相关逻辑。
-func registerRoutes(r *gin.Engine, baseRouterPrefix string, publicRoutes, privateRoutes []func(*gin.RouterGroup), authMiddlewares ...gin.HandlerFunc) {
-	baseRouter := r.Group(baseRouterPrefix)
-	for _, route := range publicRoutes {
-		route(baseRouter)
-	}
-
-	priRoute := baseRouter.Group("", authMiddlewares...)
-	for _, route := range privateRoutes {
-		route(priRoute)
-	}
-}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -68,6 +69,11 @@ func InitGormDB(gcc GormConnConfig, gormLogger logger.Interface, opt ...func(db
 	return nil
 }
 
+// WithGinContext 使用请求的 context 执行查询，请求超时或客户端断开时查询会被取消。
+func (db *GormClient) WithGinContext(c *gin.Context) *gorm.DB {
+	return db.WithContext(GinContext(c))
+}
+
 // GormDefaultLogger 函数用于处理GormDefaultLogger相关逻辑。
 func GormDefaultLogger(logLevel ...int) logger.Interface {
 	var ll int
@@ -0,0 +1,226 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjf2000/ants/v2"
+)
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+
+	defaultHealthCheckTimeout = 3 * time.Second
+)
+
+// HealthCheckFunc 健康检查函数，返回 nil 表示健康。
+type HealthCheckFunc func(ctx context.Context) error
+
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	check   HealthCheckFunc
+}
+
+// Health 健康检查注册表，分别维护存活（/livez）与就绪（/readyz）检查。
+type Health struct {
+	mu        sync.RWMutex
+	liveness  []healthCheck
+	readiness []healthCheck
+}
+
+// HealthCheckResult 单个检查项的结果。
+type HealthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport 健康检查报告。
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// NewHealth 创建空的健康检查注册表，没有检查项时始终视为健康。
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddLivenessCheck 注册存活检查，timeout 小于等于 0 时使用默认的 3 秒。
+func (h *Health) AddLivenessCheck(name string, timeout time.Duration, check HealthCheckFunc) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, healthCheck{name: name, timeout: timeout, check: check})
+	return h
+}
+
+// AddReadinessCheck 注册就绪检查，timeout 小于等于 0 时使用默认的 3 秒。
+func (h *Health) AddReadinessCheck(name string, timeout time.Duration, check HealthCheckFunc) *Health {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, healthCheck{name: name, timeout: timeout, check: check})
+	return h
+}
+
+// Liveness 执行全部存活检查。
+func (h *Health) Liveness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append([]healthCheck(nil), h.liveness...)
+	h.mu.RUnlock()
+	return runHealthChecks(ctx, checks)
+}
+
+// Readiness 执行全部就绪检查。
+func (h *Health) Readiness(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append([]healthCheck(nil), h.readiness...)
+	h.mu.RUnlock()
+	return runHealthChecks(ctx, checks)
+}
+
+// LivenessHandler 返回存活检查接口，健康时返回 200，否则返回 503。
+func (h *Health) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		responseHealthReport(c, h.Liveness(c.Request.Context()))
+	}
+}
+
+// ReadinessHandler 返回就绪检查接口，健康时返回 200，否则返回 503。
+func (h *Health) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		responseHealthReport(c, h.Readiness(c.Request.Context()))
+	}
+}
+
+// responseHealthReport 函数用于处理responseHealthReport相关逻辑。
+func responseHealthReport(c *gin.Context, report HealthReport) {
+	code := http.StatusOK
+	if report.Status != HealthStatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	ResponseThirdPartyHTTPBody(c, report, code)
+}
+
+// runHealthChecks 并发执行检查项，每个检查项使用各自的超时时间。
+func runHealthChecks(ctx context.Context, checks []healthCheck) HealthReport {
+	report := HealthReport{
+		Status: HealthStatusUp,
+		Checks: make([]HealthCheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, item := range checks {
+		wg.Add(1)
+		go func(i int, item healthCheck) {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(ctx, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+			break
+		}
+	}
+	return report
+}
+
+// runHealthCheck 执行单个检查项，检查函数不响应 ctx 时也会在超时后返回。
+func runHealthCheck(ctx context.Context, item healthCheck) HealthCheckResult {
+	timeout := item.timeout
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := HealthCheckResult{Name: item.name, Status: HealthStatusUp}
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- item.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(start).String()
+	return result
+}
+
+// HealthCheckDB 检查 InsDB 的数据库连接。
+func HealthCheckDB() HealthCheckFunc {
+	return func(ctx context.Context) error {
+		if InsDB == nil || InsDB.DB == nil {
+			return errors.New("InsDB为空,需要先使用gb.InitGormDB()进行初始化")
+		}
+		sqlDB, err := InsDB.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// HealthCheckRedis 检查 InsRedis 的连接。
+func HealthCheckRedis() HealthCheckFunc {
+	return func(ctx context.Context) error {
+		if InsRedis == nil || InsRedis.UniversalClient == nil {
+			return redisClientNilErr()
+		}
+		return InsRedis.Ping(ctx).Err()
+	}
+}
+
+// HealthCheckCornJob 检查 InsCornJob 调度器是否已启动。
+func HealthCheckCornJob() HealthCheckFunc {
+	return func(ctx context.Context) error {
+		if InsCornJob == nil {
+			return errors.New("InsCornJob为空,需要先使用gb.InitCornJob()进行初始化")
+		}
+		if !InsCornJob.Running() {
+			return errors.New("定时任务调度器未运行")
+		}
+		return nil
+	}
+}
+
+// HealthCheckAntsPool 检查协程池使用率，超过 maxUsage（0~1）视为饱和；未传入 pool 时检查 ants 默认协程池。
+func HealthCheckAntsPool(maxUsage float64, pool ...*ants.Pool) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		running, capacity := ants.Running(), ants.Cap()
+		if len(pool) > 0 && pool[0] != nil {
+			running, capacity = pool[0].Running(), pool[0].Cap()
+		}
+		if capacity <= 0 {
+			return nil
+		}
+		usage := float64(running) / float64(capacity)
+		if usage >= maxUsage {
+			return fmt.Errorf("协程池已饱和: running=%d cap=%d", running, capacity)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjf2000/ants/v2"
+)
+
+func serveHealth(t *testing.T, handler gin.HandlerFunc) (int, HealthReport) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/readyz", handler)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, report
+}
+
+func TestHealthHandlerReportsFailures(t *testing.T) {
+	// 不响应 ctx 的检查项，测试结束后才返回
+	stuck := make(chan struct{})
+	t.Cleanup(func() { close(stuck) })
+
+	health := NewHealth().
+		AddLivenessCheck("self", 0, func(context.Context) error { return nil }).
+		AddReadinessCheck("ok", 0, func(context.Context) error { return nil }).
+		AddReadinessCheck("failing", 0, func(context.Context) error { return errors.New("connection refused") }).
+		AddReadinessCheck("timeout", 20*time.Millisecond, func(context.Context) error { <-stuck; return nil }).
+		AddReadinessCheck("panicking", 0, func(context.Context) error { panic("boom") })
+
+	if code, report := serveHealth(t, health.LivenessHandler()); code != http.StatusOK || report.Status != HealthStatusUp {
+		t.Fatalf("liveness = %d %+v, want up", code, report)
+	}
+
+	start := time.Now()
+	code, report := serveHealth(t, health.ReadinessHandler())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("readiness took %s, want the stuck check to time out", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || report.Status != HealthStatusDown {
+		t.Fatalf("readiness = %d %s, want 503 down", code, report.Status)
+	}
+	want := map[string]string{
+		"ok":        "",
+		"failing":   "connection refused",
+		"timeout":   context.DeadlineExceeded.Error(),
+		"panicking": "panic: boom",
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("checks = %+v", report.Checks)
+	}
+	for _, result := range report.Checks {
+		wantErr, ok := want[result.Name]
+		wantStatus := HealthStatusDown
+		if wantErr == "" {
+			wantStatus = HealthStatusUp
+		}
+		if !ok || result.Status != wantStatus || result.Error != wantErr {
+			t.Errorf("check %s = %+v, want status %s error %q", result.Name, result, wantStatus, wantErr)
+		}
+	}
+}
+
+func TestHealthCheckAntsPoolThreshold(t *testing.T) {
+	pool, err := ants.NewPool(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Release()
+	release := make(chan struct{})
+	defer close(release)
+
+	health := NewHealth().AddReadinessCheck("pool", 0, HealthCheckAntsPool(0.75, pool))
+	for i := 1; i <= 3; i++ {
+		if err := pool.Submit(func() { <-release }); err != nil {
+			t.Fatal(err)
+		}
+		for pool.Running() != i {
+			time.Sleep(time.Millisecond)
+		}
+		code, report := serveHealth(t, health.ReadinessHandler())
+		if i < 3 && (code != http.StatusOK || report.Status != HealthStatusUp) {
+			t.Fatalf("running %d/4 = %d %+v, want up below the threshold", i, code, report)
+		}
+		if i == 3 && (code != http.StatusServiceUnavailable || !strings.Contains(report.Checks[0].Error, "running=3 cap=4")) {
+			t.Fatalf("running %d/4 = %d %+v, want saturated at the threshold", i, code, report)
+		}
+	}
+}
@@ -2,18 +2,35 @@ package gb
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
-	"log"
+	"net"
 	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
+const defaultShutdownTimeout = 10 * time.Second
+
 type HTTPServer struct {
-	server *http.Server
+	server          *http.Server
+	engine          *gin.Engine
+	shutdownTimeout time.Duration
+	config          RouterConfig
+	routes          []RouteTableEntry
+	err             error // 创建时的配置错误，由 Start、Serve、Run 返回
+
+	mu       sync.Mutex
+	listener net.Listener
+	errCh    chan error
 }
 
-// InitHTTPServerAndStart 函数用于处理InitHTTPServerAndStart相关逻辑。
-func InitHTTPServerAndStart(listenAddr string, opts ...GinRouterConfigOptionFunc) *HTTPServer {
+// NewHTTPServer 根据路由配置创建 HTTPServer，但不会启动监听；配置错误（如无效的可信代理）在 Start、Serve、Run 时返回。
+func NewHTTPServer(listenAddr string, opts ...GinRouterConfigOptionFunc) *HTTPServer {
 	var config RouterConfig
 	for _, opt := range opts {
 		opt(&config)
@@ -24,11 +41,30 @@ func InitHTTPServerAndStart(listenAddr string, opts ...GinRouterConfigOptionFunc
 	if config.prefix == "" {
 		config.prefix = "/api"
 	}
-	engine := initPrivateRouter(config)
-	server := &HTTPServer{server: &http.Server{
-		Addr:    listenAddr,
-		Handler: engine,
-	}}
+	if config.shutdownTimeout <= 0 {
+		config.shutdownTimeout = defaultShutdownTimeout
+	}
+	engine, routes, err := initPrivateRouter(config)
+	if err != nil {
+		return &HTTPServer{
+			server:          &http.Server{Addr: listenAddr},
+			engine:          gin.New(),
+			shutdownTimeout: config.shutdownTimeout,
+			config:          config,
+			err:             err,
+		}
+	}
+	engine.UseH2C = config.h2c
+	server := &HTTPServer{
+		server: &http.Server{
+			Addr:    listenAddr,
+			Handler: engine.Handler(),
+		},
+		engine:          engine,
+		shutdownTimeout: config.shutdownTimeout,
+		config:          config,
+		routes:          routes,
+	}
 	if config.readTimeout > 0 {
 		server.server.ReadTimeout = config.readTimeout
 	}
@@ -41,25 +77,122 @@ func InitHTTPServerAndStart(listenAddr string, opts ...GinRouterConfigOptionFunc
 	if config.maxHeaderBytes > 0 {
 		server.server.MaxHeaderBytes = config.maxHeaderBytes
 	}
-	go server.startHTTPServer()
-	server.setupGracefulShutdown()
 	return server
 }
 
-// startHTTPServer 方法用于处理startHTTPServer相关逻辑。
-func (h *HTTPServer) startHTTPServer() {
-	if err := h.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		panic(err)
+// InitHTTPServerAndStart 创建并启动 HTTPServer，阻塞直到收到 SIGINT/SIGTERM 后优雅关闭。
+func InitHTTPServerAndStart(listenAddr string, opts ...GinRouterConfigOptionFunc) (*HTTPServer, error) {
+	server := NewHTTPServer(listenAddr, opts...)
+	return server, server.Run(context.Background())
+}
+
+// Engine 返回底层的 gin.Engine。
+func (h *HTTPServer) Engine() *gin.Engine {
+	return h.engine
+}
+
+// Routes 返回完整的路由表，包含公开/私有标记、处理函数与中间件链。
+func (h *HTTPServer) Routes() []RouteTableEntry {
+	return BuildRouteTable(h.engine, h.routes)
+}
+
+// Addr 返回实际监听的地址，未启动时返回配置的地址。
+func (h *HTTPServer) Addr() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.listener != nil {
+		return h.listener.Addr().String()
+	}
+	return h.server.Addr
+}
+
+// Start 监听配置的地址并在后台提供服务，监听失败时直接返回错误。
+func (h *HTTPServer) Start() error {
+	if h.err != nil {
+		return h.err
+	}
+	addr := h.server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := h.Serve(ln); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	return nil
+}
+
+// Serve 在给定的 listener 上后台提供服务，便于测试时注入临时端口；配置了证书时以 HTTPS 提供服务。
+func (h *HTTPServer) Serve(ln net.Listener) error {
+	if h.err != nil {
+		return h.err
+	}
+	h.mu.Lock()
+	if h.listener != nil {
+		h.mu.Unlock()
+		return errors.New("http server 已启动")
 	}
+	var tlsConfig *tls.Config
+	if h.config.tlsEnabled() {
+		var err error
+		if tlsConfig, err = newTLSConfig(h.config); err != nil {
+			h.mu.Unlock()
+			return err
+		}
+		h.server.TLSConfig = tlsConfig
+	}
+	h.listener = ln
+	h.errCh = make(chan error, 1)
+	h.mu.Unlock()
+
+	go func() {
+		defer close(h.errCh)
+		var err error
+		if tlsConfig != nil {
+			err = h.server.ServeTLS(ln, "", "")
+		} else {
+			err = h.server.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			h.errCh <- err
+		}
+	}()
+	return nil
+}
+
+// Shutdown 优雅关闭服务，等待进行中的请求在 ctx 截止前完成。
+func (h *HTTPServer) Shutdown(ctx context.Context) error {
+	return h.server.Shutdown(ctx)
 }
 
-// setupGracefulShutdown 方法用于处理setupGracefulShutdown相关逻辑。
-func (h *HTTPServer) setupGracefulShutdown() {
-	NewHook().Close(func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
-		if err := h.server.Shutdown(ctx); err != nil {
-			log.Printf("setup graceful shutdown err: %s\n", err)
+// Run 启动服务并阻塞，直到 ctx 结束、收到 SIGINT/SIGTERM 或服务异常退出，随后按超时时间优雅关闭。
+func (h *HTTPServer) Run(ctx context.Context) error {
+	h.mu.Lock()
+	started := h.listener != nil
+	h.mu.Unlock()
+	if !started {
+		if err := h.Start(); err != nil {
+			return err
 		}
-	})
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-h.errCh:
+		return err
+	case <-sigCtx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), h.shutdownTimeout)
+	defer cancel()
+	if err := h.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return <-h.errCh
 }
@@ -0,0 +1,109 @@
+package gb
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestHTTPServer(t *testing.T, opts ...GinRouterConfigOptionFunc) *HTTPServer {
+	t.Helper()
+	opts = append([]GinRouterConfigOptionFunc{WithGinRouterModel(GinModelTest), WithGinSkipLog(true)}, opts...)
+	return NewHTTPServer("127.0.0.1:0", opts...)
+}
+
+func TestNewHTTPServerInvalidTrustedProxies(t *testing.T) {
+	server := newTestHTTPServer(t, WithGinTrustedProxies("10.0.0.0/33"))
+	if err := server.Start(); err == nil {
+		server.Shutdown(t.Context())
+		t.Fatal("Start succeeded with an invalid trusted proxy")
+	}
+}
+
+func TestHTTPServerServe(t *testing.T) {
+	router := NewRouter("/v1")
+	router.Public(func(group *gin.RouterGroup) {
+		group.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	})
+	server := newTestHTTPServer(t, WithGinRouters(router))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(ln); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	if server.Addr() != ln.Addr().String() {
+		t.Fatalf("Addr() = %s, want %s", server.Addr(), ln.Addr())
+	}
+	if err := server.Serve(ln); err == nil {
+		t.Fatal("second Serve succeeded")
+	}
+
+	for target, want := range map[string]int{"/v1/hello": http.StatusOK, "/api/healthz": http.StatusOK} {
+		resp, err := http.Get("http://" + server.Addr() + target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("GET %s = %d, want %d", target, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestHTTPServerRunGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	router := NewRouter("")
+	router.Public(func(group *gin.RouterGroup) {
+		group.GET("/slow", func(c *gin.Context) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			c.String(http.StatusOK, "done")
+		})
+	})
+	server := newTestHTTPServer(t, WithGinRouters(router), WithGinShutdownTimeout(time.Second))
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- server.Run(ctx) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + server.Addr() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+
+	<-started
+	cancel()
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request got %q, want it to finish before shutdown", got)
+	}
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after ctx was cancelled")
+	}
+	if _, err := http.Get("http://" + server.Addr() + "/slow"); err == nil {
+		t.Fatal("server still accepts requests after Run returned")
+	}
+}
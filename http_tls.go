@@ -0,0 +1,205 @@
+package gb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const certReloadCheckInterval = time.Second
+
+// WithGinTLSCertFile 设置 HTTPS 证书与私钥文件，文件变更后会自动热加载。
+func WithGinTLSCertFile(certFile, keyFile string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.tlsCertFile = certFile
+		config.tlsKeyFile = keyFile
+	}
+}
+
+// WithGinTLSClientCAFiles 设置用于校验客户端证书的 CA 文件，开启双向 TLS。
+func WithGinTLSClientCAFiles(caFiles ...string) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.tlsClientCAFiles = append(config.tlsClientCAFiles, caFiles...)
+	}
+}
+
+// WithGinTLSClientCAPool 设置用于校验客户端证书的 CA 池，开启双向 TLS。
+func WithGinTLSClientCAPool(pool *x509.CertPool) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.tlsClientCAs = pool
+	}
+}
+
+// WithGinTLSMinVersion 设置最低 TLS 版本，默认 tls.VersionTLS12。
+func WithGinTLSMinVersion(version uint16) GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.tlsMinVersion = version
+	}
+}
+
+// WithGinH2C 开启明文 HTTP/2（h2c）支持。
+func WithGinH2C() GinRouterConfigOptionFunc {
+	return func(config *RouterConfig) {
+		config.h2c = true
+	}
+}
+
+// tlsEnabled 判断是否配置了证书。
+func (config RouterConfig) tlsEnabled() bool {
+	return config.tlsCertFile != "" || config.tlsKeyFile != ""
+}
+
+// newTLSConfig 根据路由配置构建 tls.Config，证书与客户端 CA 文件变更后都会在握手时热加载。
+func newTLSConfig(config RouterConfig) (*tls.Config, error) {
+	if config.tlsCertFile == "" || config.tlsKeyFile == "" {
+		return nil, errors.New("证书文件和私钥文件不能为空")
+	}
+	reloader, err := newCertReloader(config.tlsCertFile, config.tlsKeyFile, config.tlsClientCAFiles, config.tlsClientCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: reloader.GetCertificate,
+	}
+	if config.tlsMinVersion > 0 {
+		tlsConfig.MinVersion = config.tlsMinVersion
+	}
+	if reloader.mutual() {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		// ClientCAs 只能在握手前通过 GetConfigForClient 替换，返回的配置不再经过 http.Server 的处理，因此显式设置 NextProtos
+		base := tlsConfig.Clone()
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			clientConfig := base.Clone()
+			clientConfig.ClientCAs = reloader.ClientCAs()
+			return clientConfig, nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// certReloader 在握手时按需检查证书、私钥与客户端 CA 文件的修改时间并重新加载。
+type certReloader struct {
+	certFile  string
+	keyFile   string
+	caFiles   []string
+	caPool    *x509.CertPool // WithGinTLSClientCAPool 设置的固定 CA，重新加载时在其基础上追加 caFiles
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// newCertReloader 函数用于处理newCertReloader相关逻辑。
+func newCertReloader(certFile, keyFile string, caFiles []string, caPool *x509.CertPool) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFiles: caFiles, caPool: caPool}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// mutual 是否配置了客户端 CA，即开启双向 TLS。
+func (r *certReloader) mutual() bool {
+	return r.caPool != nil || len(r.caFiles) > 0
+}
+
+// latestModTime 返回证书、私钥与 CA 文件中最新的修改时间。
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range append([]string{r.certFile, r.keyFile}, r.caFiles...) {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// load 加载证书与客户端 CA，任一文件无效时保留原有配置。
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	pool := r.caPool
+	if len(r.caFiles) > 0 {
+		if pool == nil {
+			pool = x509.NewCertPool()
+		} else {
+			pool = pool.Clone()
+		}
+		for _, caFile := range r.caFiles {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("CA 文件 %s 中没有有效证书", caFile)
+			}
+		}
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// reloadIfChanged 距上次检查超过 certReloadCheckInterval 时检查文件是否变更。
+func (r *certReloader) reloadIfChanged() {
+	r.mu.Lock()
+	shouldCheck := time.Since(r.lastCheck) >= certReloadCheckInterval
+	if shouldCheck {
+		r.lastCheck = time.Now()
+	}
+	r.mu.Unlock()
+	if !shouldCheck {
+		return
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return
+	}
+	r.mu.RLock()
+	changed := modTime.After(r.modTime)
+	r.mu.RUnlock()
+	if changed {
+		if err := r.load(modTime); err != nil {
+			log.Printf("reload tls certificate err: %s\n", err)
+		}
+	}
+}
+
+// GetCertificate 方法用于处理GetCertificate相关逻辑。
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// ClientCAs 返回当前用于校验客户端证书的 CA 池。
+func (r *certReloader) ClientCAs() *x509.CertPool {
+	r.reloadIfChanged()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clientCAs
+}
@@ -0,0 +1,206 @@
+package gb
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert 签发测试证书，parent 为 nil 时生成自签名 CA。
+func newTestCert(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// writeTestFile 写入文件并把修改时间推到 offset 之后，避免文件系统时间精度导致热加载检测不到变更。
+func writeTestFile(t *testing.T, name string, data []byte, offset time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(offset)
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startTLSTestServer(t *testing.T, opts ...GinRouterConfigOptionFunc) *HTTPServer {
+	t.Helper()
+	router := NewRouter("/v1")
+	router.Public(func(group *gin.RouterGroup) {
+		group.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, c.Request.Proto) })
+	})
+	server := newTestHTTPServer(t, append([]GinRouterConfigOptionFunc{WithGinRouters(router)}, opts...)...)
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+	return server
+}
+
+// getTLS 每次使用新连接请求，确保重新握手。
+func getTLS(server *HTTPServer, roots *x509.CertPool, clientCert *testCert) (*http.Response, error) {
+	tlsConfig := &tls.Config{RootCAs: roots}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{clientCert.tlsCertificate()}
+	}
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}}
+	resp, err := client.Get("https://" + server.Addr() + "/v1/hello")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+func TestHTTPServerTLSHotReload(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, 0)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	first := newTestCert(t, "server-1", ca, x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, first.certPEM, 0)
+	writeTestFile(t, keyFile, first.keyPEM, 0)
+
+	server := startTLSTestServer(t, WithGinTLSCertFile(certFile, keyFile))
+	resp, err := getTLS(server, roots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cn := resp.TLS.PeerCertificates[0].Subject.CommonName; cn != "server-1" || resp.ProtoMajor != 2 {
+		t.Fatalf("served %s over %s, want server-1 over HTTP/2", cn, resp.Proto)
+	}
+
+	second := newTestCert(t, "server-2", ca, x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, second.certPEM, 2*time.Second)
+	writeTestFile(t, keyFile, second.keyPEM, 2*time.Second)
+	time.Sleep(certReloadCheckInterval + 100*time.Millisecond)
+
+	resp, err = getTLS(server, roots, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cn := resp.TLS.PeerCertificates[0].Subject.CommonName; cn != "server-2" {
+		t.Fatalf("served %s after swapping files, want server-2", cn)
+	}
+}
+
+func TestHTTPServerMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, 0)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCA1 := newTestCert(t, "client-ca-1", nil, 0)
+	clientCA2 := newTestCert(t, "client-ca-2", nil, 0)
+	client1 := newTestCert(t, "client-1", clientCA1, x509.ExtKeyUsageClientAuth)
+	client2 := newTestCert(t, "client-2", clientCA2, x509.ExtKeyUsageClientAuth)
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "client-ca.crt")
+	serverCert := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth)
+	writeTestFile(t, certFile, serverCert.certPEM, 0)
+	writeTestFile(t, keyFile, serverCert.keyPEM, 0)
+	writeTestFile(t, caFile, clientCA1.certPEM, 0)
+
+	server := startTLSTestServer(t, WithGinTLSCertFile(certFile, keyFile), WithGinTLSClientCAFiles(caFile))
+	if _, err := getTLS(server, roots, nil); err == nil {
+		t.Fatal("request without client certificate succeeded")
+	}
+	resp, err := getTLS(server, roots, client1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+		t.Fatalf("mutual TLS request = %d over %s", resp.StatusCode, resp.Proto)
+	}
+
+	// 轮换客户端 CA 后旧 CA 签发的证书不再被接受
+	writeTestFile(t, caFile, clientCA2.certPEM, 2*time.Second)
+	time.Sleep(certReloadCheckInterval + 100*time.Millisecond)
+	if _, err := getTLS(server, roots, client1); err == nil {
+		t.Fatal("client certificate from the rotated-out CA was accepted")
+	}
+	if _, err := getTLS(server, roots, client2); err != nil {
+		t.Fatalf("client certificate from the new CA rejected: %v", err)
+	}
+}
+
+func TestHTTPServerH2C(t *testing.T) {
+	server := startTLSTestServer(t, WithGinH2C())
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	resp, err := client.Get("http://" + server.Addr() + "/v1/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+		t.Fatalf("h2c request = %d over %s, want HTTP/2", resp.StatusCode, resp.Proto)
+	}
+}
@@ -0,0 +1,210 @@
+package gb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyRingConfig 密钥环配置，可嵌入业务配置中由 InitConfig 加载。
+type KeyRingConfig struct {
+	ActiveKid       string            `json:"active_kid" yaml:"active_kid"`               // 当前用于加密的密钥编号
+	Keys            map[string]string `json:"keys" yaml:"keys"`                           // 密钥编号 -> 密钥，非活动密钥仅用于解密
+	AllowMissingKid bool              `json:"allow_missing_kid" yaml:"allow_missing_kid"` // 是否接受不带 kid 的信封（EncryptData 生成），默认拒绝，仅在迁移旧客户端时开启
+}
+
+// KeyRing 带编号的 AES 密钥集合：活动密钥用于加密，已退役的密钥仍可解密旧数据，便于密钥轮换。
+type KeyRing struct {
+	mu              sync.RWMutex
+	active          string
+	keys            map[string][]byte
+	allowMissingKid bool
+}
+
+// NewKeyRing 创建密钥环，所有密钥在创建时校验长度。
+func NewKeyRing(config KeyRingConfig) (*KeyRing, error) {
+	kr := &KeyRing{keys: make(map[string][]byte, len(config.Keys)), allowMissingKid: config.AllowMissingKid}
+	for kid, key := range config.Keys {
+		if err := kr.AddKey(kid, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := kr.SetActive(config.ActiveKid); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// NewKeyRingFromEnv 从环境变量加载密钥环，prefix 默认为 GB_ENCRYPT：
+// GB_ENCRYPT_ACTIVE_KID=v2
+// GB_ENCRYPT_KEYS=v1:0123456789abcdef,v2:fedcba9876543210
+// GB_ENCRYPT_ALLOW_MISSING_KID=true（可选）
+func NewKeyRingFromEnv(prefix ...string) (*KeyRing, error) {
+	p := "GB_ENCRYPT"
+	if len(prefix) > 0 && prefix[0] != "" {
+		p = prefix[0]
+	}
+	config := KeyRingConfig{
+		ActiveKid: os.Getenv(p + "_ACTIVE_KID"),
+		Keys:      make(map[string]string),
+	}
+	if value := os.Getenv(p + "_ALLOW_MISSING_KID"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s_ALLOW_MISSING_KID格式错误: %w", p, err)
+		}
+		config.AllowMissingKid = allow
+	}
+	for _, item := range strings.Split(os.Getenv(p+"_KEYS"), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kid, key, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s_KEYS格式错误,应为kid:key,kid:key", p)
+		}
+		config.Keys[strings.TrimSpace(kid)] = key
+	}
+	return NewKeyRing(config)
+}
+
+// AddKey 添加密钥，已存在的编号会被覆盖。
+func (kr *KeyRing) AddKey(kid, key string) error {
+	if kid == "" {
+		return fmt.Errorf("密钥编号不能为空")
+	}
+	if err := validateAESKey([]byte(key)); err != nil {
+		return fmt.Errorf("密钥%s: %w", kid, err)
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys[kid] = []byte(key)
+	return nil
+}
+
+// SetActive 切换活动密钥，原活动密钥自动成为仅解密的退役密钥。
+func (kr *KeyRing) SetActive(kid string) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.keys[kid]; !ok {
+		return fmt.Errorf("活动密钥%s不存在", kid)
+	}
+	kr.active = kid
+	return nil
+}
+
+// RemoveKey 删除退役密钥，活动密钥不能删除。
+func (kr *KeyRing) RemoveKey(kid string) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if kid == kr.active {
+		return fmt.Errorf("不能删除活动密钥%s", kid)
+	}
+	delete(kr.keys, kid)
+	return nil
+}
+
+// ActiveKid 返回当前活动密钥编号。
+func (kr *KeyRing) ActiveKid() string {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.active
+}
+
+// Kids 返回全部密钥编号。
+func (kr *KeyRing) Kids() []string {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	kids := make([]string, 0, len(kr.keys))
+	for kid := range kr.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+// keyRingAAD 将 kid 与时间戳作为 GCM 附加认证数据（格式为 "kid|timestamp"），篡改任一字段都会导致解密失败。
+func keyRingAAD(kid string, timestamp int64) []byte {
+	return []byte(kid + "|" + strconv.FormatInt(timestamp, 10))
+}
+
+// Encrypt 使用活动密钥加密，并在 EncryptedResponse 中写入 kid，kid 与 timestamp 参与认证（见 keyRingAAD）。
+func (kr *KeyRing) Encrypt(data any) (*EncryptedResponse, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	kr.mu.RLock()
+	kid, key := kr.active, kr.keys[kr.active]
+	kr.mu.RUnlock()
+
+	now := time.Now().Unix()
+	encryptedData, err := encryptAESGCM(jsonData, key, keyRingAAD(kid, now))
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateNonce(16)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedResponse{
+		Data:      encryptedData,
+		Timestamp: now,
+		Nonce:     nonce,
+		Kid:       kid,
+	}, nil
+}
+
+// DecryptBytes 按 kid 选择密钥解密并校验 kid 与 timestamp。
+// kid 为空时视为 EncryptData 生成的格式，仅在 AllowMissingKid 开启时使用活动密钥解密并校验 timestamp。
+func (kr *KeyRing) DecryptBytes(encrypted *EncryptedResponse) ([]byte, error) {
+	if encrypted == nil {
+		return nil, fmt.Errorf("encrypted data is nil")
+	}
+	kid := encrypted.Kid
+	if kid == "" && !kr.allowMissingKid {
+		return nil, fmt.Errorf("缺少密钥编号")
+	}
+	kr.mu.RLock()
+	if kid == "" {
+		kid = kr.active
+	}
+	key, ok := kr.keys[kid]
+	kr.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("密钥%s不存在", kid)
+	}
+	if encrypted.Kid == "" {
+		return decryptAESGCM(encrypted.Data, key, timestampAAD(encrypted.Timestamp))
+	}
+	return decryptAESGCM(encrypted.Data, key, keyRingAAD(encrypted.Kid, encrypted.Timestamp))
+}
+
+// Decrypt 解密后反序列化到 v。
+func (kr *KeyRing) Decrypt(encrypted *EncryptedResponse, v any) error {
+	plaintext, err := kr.DecryptBytes(encrypted)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plaintext, v)
+}
+
+// ResponseSuccessKeyRingEncryptData 使用密钥环加密响应数据。
+func ResponseSuccessKeyRingEncryptData(c *gin.Context, data interface{}, kr *KeyRing) {
+	response, err := kr.Encrypt(data)
+	if err != nil {
+		ResponseError(c, EncryptErr)
+		return
+	}
+	ResponseSuccess(c, response)
+}
@@ -0,0 +1,138 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestKeyRing(t *testing.T) *KeyRing {
+	t.Helper()
+	kr, err := NewKeyRing(KeyRingConfig{
+		ActiveKid: "v2",
+		Keys: map[string]string{
+			"v1": "0123456789abcdef",
+			"v2": "0123456789abcdef0123456789abcdef",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	kr := newTestKeyRing(t)
+	kr.SetActive("v1")
+	old, err := kr.Encrypt(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr.SetActive("v2")
+	var v map[string]int
+	if err := kr.Decrypt(old, &v); err != nil || v["a"] != 1 {
+		t.Fatalf("decrypt with retired key: %v, %v", v, err)
+	}
+}
+
+func TestKeyRingAuthenticatesEnvelope(t *testing.T) {
+	kr := newTestKeyRing(t)
+	encrypted, err := kr.Encrypt(map[string]string{"name": "张三"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *encrypted
+	tampered.Timestamp += 60
+	if _, err := kr.DecryptBytes(&tampered); err == nil {
+		t.Fatal("modified timestamp was accepted")
+	}
+	tampered = *encrypted
+	tampered.Kid = ""
+	if _, err := kr.DecryptBytes(&tampered); err == nil {
+		t.Fatal("removed kid was accepted")
+	}
+}
+
+func TestKeyRingRejectsMissingKidByDefault(t *testing.T) {
+	kr := newTestKeyRing(t)
+	legacy, _ := EncryptData(map[string]int{"a": 1}, func(int64) (string, string) {
+		return "0123456789abcdef0123456789abcdef", ""
+	})
+	if _, err := kr.DecryptBytes(legacy); err == nil {
+		t.Fatal("envelope without kid was accepted")
+	}
+
+	now := time.Now()
+	engine := newDecryptTestEngine(DecryptBodyConfig{KeyRing: kr}, &now)
+	if resp := postEncrypted(engine, legacy); resp.Code != DecryptErr.Code {
+		t.Fatalf("middleware accepted envelope without kid: %+v", resp)
+	}
+}
+
+func TestDecryptBodyMissingKidRejectsForgedTimestamp(t *testing.T) {
+	kr := newTestKeyRing(t)
+	kr.allowMissingKid = true
+	now := time.Now()
+	engine := newDecryptTestEngine(DecryptBodyConfig{KeyRing: kr}, &now)
+	legacy, _ := EncryptData(map[string]int{"a": 1}, func(int64) (string, string) {
+		return "0123456789abcdef0123456789abcdef", ""
+	})
+	if resp := postEncrypted(engine, legacy); resp.Code != http.StatusOK {
+		t.Fatalf("fresh envelope without kid rejected: %+v", resp)
+	}
+	now = now.Add(time.Hour)
+	legacy.Timestamp = now.Unix()
+	if resp := postEncrypted(engine, legacy); resp.Code != DecryptErr.Code {
+		t.Fatalf("replayed envelope without kid and forged timestamp = %+v, want DecryptErr", resp)
+	}
+}
+
+func TestKeyRingDecryptsLegacyEnvelope(t *testing.T) {
+	kr := newTestKeyRing(t)
+	kr.allowMissingKid = true
+	legacy, err := EncryptData(map[string]int{"a": 1}, func(int64) (string, string) {
+		return "0123456789abcdef0123456789abcdef", ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]int
+	if err := kr.Decrypt(legacy, &v); err != nil || v["a"] != 1 {
+		t.Fatalf("decrypt legacy envelope: %v, %v", v, err)
+	}
+}
+
+func TestDecryptBodyRejectsReplayedBodyWithNewTimestamp(t *testing.T) {
+	kr := newTestKeyRing(t)
+	now := time.Now()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/x", MiddlewareDecryptBody(DecryptBodyConfig{KeyRing: kr, Now: func() time.Time { return now }}), func(c *gin.Context) {
+		ResponseSuccess(c, nil)
+	})
+	post := func(encrypted *EncryptedResponse) Response {
+		body, _ := json.Marshal(encrypted)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", bytes.NewReader(body)))
+		var resp Response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	encrypted, _ := kr.Encrypt(map[string]int{"a": 1})
+	if resp := post(encrypted); resp.Code != http.StatusOK {
+		t.Fatalf("fresh body rejected: %+v", resp)
+	}
+	// 一小时后重放，并把时间戳改为当前时间以绕过时间窗口
+	now = now.Add(time.Hour)
+	encrypted.Timestamp = now.Unix()
+	if resp := post(encrypted); resp.Code == http.StatusOK {
+		t.Fatal("replayed body with forged timestamp was accepted")
+	}
+}
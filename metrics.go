@@ -0,0 +1,205 @@
+package gb
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/panjf2000/ants/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsRegistry gb 使用的 Prometheus 注册表，业务指标也可以注册到这里一并输出。
+var MetricsRegistry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gb_http_requests_total",
+		Help: "HTTP 请求总数",
+	}, []string{"method", "route", "status", "resp_status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gb_http_request_duration_seconds",
+		Help:    "HTTP 请求耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status", "resp_status"})
+
+	cronJobRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gb_cron_job_runs_total",
+		Help: "定时任务执行次数",
+	}, []string{"job", "result"})
+
+	cronJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gb_cron_job_duration_seconds",
+		Help:    "定时任务执行耗时",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"job"})
+
+	bulkheadRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gb_bulkhead_rejected_total",
+		Help: "舱壁限流拒绝的请求数",
+	}, []string{"bulkhead"})
+
+	antsPools sync.Map // name -> *ants.Pool
+)
+
+// init 函数用于处理init相关逻辑。
+func init() {
+	MetricsRegistry.MustRegister(
+		httpRequestsTotal,
+		httpRequestDuration,
+		cronJobRunsTotal,
+		cronJobDuration,
+		bulkheadRejectedTotal,
+		newGBCollector(),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// MiddlewareMetrics 按路由模板与业务 resp-status 记录请求数与耗时。
+func MiddlewareMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := prometheus.Labels{
+			"method":      c.Request.Method,
+			"route":       route,
+			"status":      strconv.Itoa(c.Writer.Status()),
+			"resp_status": strconv.Itoa(c.GetInt("resp-status")),
+		}
+		httpRequestsTotal.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsHandler 返回 Prometheus 文本格式的指标接口。
+func MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(MetricsRegistry, promhttp.HandlerOpts{}))
+}
+
+// RegisterAntsPoolMetrics 将自建的 ants 协程池加入指标采集，默认协程池以 default 名称自动采集。
+func RegisterAntsPoolMetrics(name string, pool *ants.Pool) {
+	antsPools.Store(name, pool)
+}
+
+// cronMetricsMonitor 实现 gocron.MonitorStatus，每次执行结束时记录次数与耗时。
+type cronMetricsMonitor struct{}
+
+// IncrementJob 次数在 RecordJobTimingWithStatus 中按结果记录，这里不重复计数。
+func (cronMetricsMonitor) IncrementJob(uuid.UUID, string, []string, gocron.JobStatus) {}
+
+// RecordJobTiming 耗时在 RecordJobTimingWithStatus 中记录。
+func (cronMetricsMonitor) RecordJobTiming(time.Time, time.Time, uuid.UUID, string, []string) {}
+
+// RecordJobTimingWithStatus 方法用于处理RecordJobTimingWithStatus相关逻辑。
+func (cronMetricsMonitor) RecordJobTimingWithStatus(start, end time.Time, _ uuid.UUID, name string, _ []string, status gocron.JobStatus, _ error) {
+	result := "success"
+	if status != gocron.Success {
+		result = "error"
+	}
+	cronJobRunsTotal.WithLabelValues(name, result).Inc()
+	cronJobDuration.WithLabelValues(name).Observe(end.Sub(start).Seconds())
+}
+
+// gbCollector 在采集时读取 InsDB、InsRedis 与 ants 协程池的实时状态。
+type gbCollector struct {
+	dbOpen         *prometheus.Desc
+	dbInUse        *prometheus.Desc
+	dbIdle         *prometheus.Desc
+	dbMaxOpen      *prometheus.Desc
+	dbWaitCount    *prometheus.Desc
+	dbWaitDuration *prometheus.Desc
+
+	redisHits       *prometheus.Desc
+	redisMisses     *prometheus.Desc
+	redisTimeouts   *prometheus.Desc
+	redisTotalConns *prometheus.Desc
+	redisIdleConns  *prometheus.Desc
+	redisStaleConns *prometheus.Desc
+
+	antsRunning *prometheus.Desc
+	antsFree    *prometheus.Desc
+	antsCap     *prometheus.Desc
+}
+
+// newGBCollector 函数用于处理newGBCollector相关逻辑。
+func newGBCollector() *gbCollector {
+	pool := []string{"pool"}
+	return &gbCollector{
+		dbOpen:         prometheus.NewDesc("gb_db_open_connections", "数据库已建立的连接数", nil, nil),
+		dbInUse:        prometheus.NewDesc("gb_db_in_use_connections", "数据库使用中的连接数", nil, nil),
+		dbIdle:         prometheus.NewDesc("gb_db_idle_connections", "数据库空闲连接数", nil, nil),
+		dbMaxOpen:      prometheus.NewDesc("gb_db_max_open_connections", "数据库最大连接数", nil, nil),
+		dbWaitCount:    prometheus.NewDesc("gb_db_wait_count_total", "等待数据库连接的次数", nil, nil),
+		dbWaitDuration: prometheus.NewDesc("gb_db_wait_duration_seconds_total", "等待数据库连接的总耗时", nil, nil),
+
+		redisHits:       prometheus.NewDesc("gb_redis_pool_hits_total", "redis 连接池命中次数", nil, nil),
+		redisMisses:     prometheus.NewDesc("gb_redis_pool_misses_total", "redis 连接池未命中次数", nil, nil),
+		redisTimeouts:   prometheus.NewDesc("gb_redis_pool_timeouts_total", "redis 连接池等待超时次数", nil, nil),
+		redisTotalConns: prometheus.NewDesc("gb_redis_pool_total_connections", "redis 连接池连接数", nil, nil),
+		redisIdleConns:  prometheus.NewDesc("gb_redis_pool_idle_connections", "redis 连接池空闲连接数", nil, nil),
+		redisStaleConns: prometheus.NewDesc("gb_redis_pool_stale_connections_total", "redis 连接池淘汰的连接数", nil, nil),
+
+		antsRunning: prometheus.NewDesc("gb_ants_pool_running", "协程池运行中的协程数", pool, nil),
+		antsFree:    prometheus.NewDesc("gb_ants_pool_free", "协程池空闲容量", pool, nil),
+		antsCap:     prometheus.NewDesc("gb_ants_pool_capacity", "协程池容量", pool, nil),
+	}
+}
+
+// Describe 方法用于处理Describe相关逻辑。
+func (gc *gbCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		gc.dbOpen, gc.dbInUse, gc.dbIdle, gc.dbMaxOpen, gc.dbWaitCount, gc.dbWaitDuration,
+		gc.redisHits, gc.redisMisses, gc.redisTimeouts, gc.redisTotalConns, gc.redisIdleConns, gc.redisStaleConns,
+		gc.antsRunning, gc.antsFree, gc.antsCap,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect 方法用于处理Collect相关逻辑。
+func (gc *gbCollector) Collect(ch chan<- prometheus.Metric) {
+	if InsDB != nil && InsDB.DB != nil {
+		if sqlDB, err := InsDB.DB.DB(); err == nil {
+			stats := sqlDB.Stats()
+			ch <- prometheus.MustNewConstMetric(gc.dbOpen, prometheus.GaugeValue, float64(stats.OpenConnections))
+			ch <- prometheus.MustNewConstMetric(gc.dbInUse, prometheus.GaugeValue, float64(stats.InUse))
+			ch <- prometheus.MustNewConstMetric(gc.dbIdle, prometheus.GaugeValue, float64(stats.Idle))
+			ch <- prometheus.MustNewConstMetric(gc.dbMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+			ch <- prometheus.MustNewConstMetric(gc.dbWaitCount, prometheus.CounterValue, float64(stats.WaitCount))
+			ch <- prometheus.MustNewConstMetric(gc.dbWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+		}
+	}
+
+	if InsRedis != nil && InsRedis.UniversalClient != nil {
+		stats := InsRedis.PoolStats()
+		ch <- prometheus.MustNewConstMetric(gc.redisHits, prometheus.CounterValue, float64(stats.Hits))
+		ch <- prometheus.MustNewConstMetric(gc.redisMisses, prometheus.CounterValue, float64(stats.Misses))
+		ch <- prometheus.MustNewConstMetric(gc.redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+		ch <- prometheus.MustNewConstMetric(gc.redisTotalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+		ch <- prometheus.MustNewConstMetric(gc.redisIdleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+		ch <- prometheus.MustNewConstMetric(gc.redisStaleConns, prometheus.CounterValue, float64(stats.StaleConns))
+	}
+
+	ch <- prometheus.MustNewConstMetric(gc.antsRunning, prometheus.GaugeValue, float64(ants.Running()), "default")
+	ch <- prometheus.MustNewConstMetric(gc.antsFree, prometheus.GaugeValue, float64(ants.Free()), "default")
+	ch <- prometheus.MustNewConstMetric(gc.antsCap, prometheus.GaugeValue, float64(ants.Cap()), "default")
+	antsPools.Range(func(key, value any) bool {
+		name, pool := key.(string), value.(*ants.Pool)
+		ch <- prometheus.MustNewConstMetric(gc.antsRunning, prometheus.GaugeValue, float64(pool.Running()), name)
+		ch <- prometheus.MustNewConstMetric(gc.antsFree, prometheus.GaugeValue, float64(pool.Free()), name)
+		ch <- prometheus.MustNewConstMetric(gc.antsCap, prometheus.GaugeValue, float64(pool.Cap()), name)
+		return true
+	})
+}
@@ -0,0 +1,112 @@
+package gb
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+)
+
+// scrapeMetrics 通过 MetricsHandler 读取 Prometheus 文本格式的指标。
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/metrics", MetricsHandler())
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func assertMetric(t *testing.T, metrics, series string) {
+	t.Helper()
+	for _, line := range strings.Split(metrics, "\n") {
+		if strings.HasPrefix(line, series) {
+			return
+		}
+	}
+	t.Fatalf("series %s not found in scrape", series)
+}
+
+func TestMetricsHTTPSeries(t *testing.T) {
+	setupTestRedis(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareMetrics())
+	engine.GET("/metrics-test/users/:id", func(c *gin.Context) { ResponseSuccess(c, nil) })
+	for _, target := range []string{"/metrics-test/users/1", "/metrics-test/users/2", "/metrics-test/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	metrics := scrapeMetrics(t)
+	assertMetric(t, metrics, `gb_http_requests_total{method="GET",resp_status="200",route="/metrics-test/users/:id",status="200"} 2`)
+	assertMetric(t, metrics, `gb_http_request_duration_seconds_count{method="GET",resp_status="200",route="/metrics-test/users/:id",status="200"} 2`)
+	assertMetric(t, metrics, `gb_http_requests_total{method="GET",resp_status="0",route="unmatched",status="404"}`)
+	if strings.Contains(metrics, `route="/metrics-test/users/1"`) {
+		t.Fatal("raw path used as route label")
+	}
+	assertMetric(t, metrics, `gb_redis_pool_total_connections`)
+	assertMetric(t, metrics, `gb_ants_pool_capacity{pool="default"}`)
+	assertMetric(t, metrics, `go_goroutines`)
+}
+
+func TestCronMetricsOverlappingRuns(t *testing.T) {
+	monitor := cronMetricsMonitor{}
+	jobID := uuid.New()
+	start := time.Now()
+	// 同一任务的两次执行重叠：第二次在第一次结束前开始
+	monitor.RecordJobTimingWithStatus(start, start.Add(3*time.Second), jobID, "overlap", nil, gocron.Success, nil)
+	monitor.RecordJobTimingWithStatus(start.Add(time.Second), start.Add(2*time.Second), jobID, "overlap", nil, gocron.Fail, errors.New("boom"))
+
+	metrics := scrapeMetrics(t)
+	assertMetric(t, metrics, `gb_cron_job_duration_seconds_sum{job="overlap"} 4`)
+	assertMetric(t, metrics, `gb_cron_job_duration_seconds_count{job="overlap"} 2`)
+	assertMetric(t, metrics, `gb_cron_job_runs_total{job="overlap",result="success"} 1`)
+	assertMetric(t, metrics, `gb_cron_job_runs_total{job="overlap",result="error"} 1`)
+}
+
+func TestCronMetricsRecordedByScheduler(t *testing.T) {
+	if err := InitCornJob(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		InsCornJob.Scheduler.Shutdown()
+		InsCornJob = nil
+	}()
+	done := make(chan struct{}, 1)
+	job, err := InsCornJob.RunJob(gocron.DurationJob(time.Hour), gocron.NewTask(func() error {
+		defer func() { done <- struct{}{} }()
+		return errors.New("failed")
+	}), gocron.WithName("metrics-wiring"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	InsCornJob.Scheduler.Start()
+	if err := job.RunNow(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		metrics := scrapeMetrics(t)
+		if strings.Contains(metrics, `gb_cron_job_runs_total{job="metrics-wiring",result="error"} 1`) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scheduler run was not recorded in gb_cron_job_runs_total")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,167 @@
+package gb
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BulkheadConfig struct {
+	MaxConcurrent int           // 最大并发请求数
+	MaxWait       time.Duration // 达到并发上限后的最长排队时间，0 表示立即拒绝
+	MaxQueue      int           // 最大排队请求数，默认等于 MaxConcurrent
+
+	// 自适应模式：最近 Window 个请求的 p99 耗时超过 P99Threshold 时，并发上限降为 AdaptiveConcurrent 且不再排队
+	P99Threshold       time.Duration // 大于 0 时开启自适应模式
+	Window             int           // 统计窗口大小，默认 200
+	AdaptiveConcurrent int           // 过载时的并发上限，默认 MaxConcurrent 的 1/4（至少为 1）
+}
+
+// Bulkhead 舱壁隔离：限制一组路由的并发数，使导出等慢接口打满时不拖垮登录等核心接口。
+type Bulkhead struct {
+	name     string
+	config   BulkheadConfig
+	sem      chan struct{}
+	inFlight atomic.Int64
+	waiting  atomic.Int64
+
+	overloaded atomic.Bool
+	mu         sync.Mutex
+	samples    []time.Duration
+	next       int
+	count      int
+}
+
+// NewBulkhead 创建舱壁，同一实例的 Middleware 可以挂到多个路由或分组上共享并发额度。
+func NewBulkhead(name string, config BulkheadConfig) *Bulkhead {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 100
+	}
+	if config.MaxQueue <= 0 {
+		config.MaxQueue = config.MaxConcurrent
+	}
+	if config.Window <= 0 {
+		config.Window = 200
+	}
+	if config.AdaptiveConcurrent <= 0 {
+		config.AdaptiveConcurrent = max(1, config.MaxConcurrent/4)
+	}
+	return &Bulkhead{
+		name:    name,
+		config:  config,
+		sem:     make(chan struct{}, config.MaxConcurrent),
+		samples: make([]time.Duration, config.Window),
+	}
+}
+
+// Middleware 返回并发限制中间件，超出限制的请求返回 ErrServerBusy。
+func (b *Bulkhead) Middleware() gin.HandlerFunc {
+	return b.handle
+}
+
+// handle 方法用于处理handle相关逻辑。
+func (b *Bulkhead) handle(c *gin.Context) {
+	if !b.acquire(c) {
+		bulkheadRejectedTotal.WithLabelValues(b.name).Inc()
+		c.Header("Retry-After", "1")
+		ResponseError(c, ErrServerBusy.WithMessage("服务繁忙,请稍后再试"))
+		c.Abort()
+		return
+	}
+	defer b.release()
+
+	// 从拿到并发额度开始计时，排队与前置中间件的耗时不计入 p99，避免过载判断自我强化
+	start := Now()
+	c.Next()
+	if b.config.P99Threshold > 0 {
+		b.observe(Now().Sub(start))
+	}
+}
+
+// InFlight 返回当前正在处理的请求数。
+func (b *Bulkhead) InFlight() int {
+	return int(b.inFlight.Load())
+}
+
+// Overloaded 返回自适应模式下是否处于过载状态。
+func (b *Bulkhead) Overloaded() bool {
+	return b.overloaded.Load()
+}
+
+// acquire 方法用于处理acquire相关逻辑。
+func (b *Bulkhead) acquire(c *gin.Context) bool {
+	overloaded := b.overloaded.Load()
+	if overloaded && b.inFlight.Load() >= int64(b.config.AdaptiveConcurrent) {
+		return false
+	}
+
+	select {
+	case b.sem <- struct{}{}:
+		b.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	if overloaded || b.config.MaxWait <= 0 {
+		return false
+	}
+	if b.waiting.Add(1) > int64(b.config.MaxQueue) {
+		b.waiting.Add(-1)
+		return false
+	}
+	defer b.waiting.Add(-1)
+
+	timer := time.NewTimer(b.config.MaxWait)
+	defer timer.Stop()
+	select {
+	case b.sem <- struct{}{}:
+		b.inFlight.Add(1)
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+// release 方法用于处理release相关逻辑。
+func (b *Bulkhead) release() {
+	b.inFlight.Add(-1)
+	<-b.sem
+}
+
+// observe 记录请求耗时，每积累 1/10 个窗口重新计算一次 p99。
+func (b *Bulkhead) observe(latency time.Duration) {
+	b.mu.Lock()
+	b.samples[b.next] = latency
+	b.next = (b.next + 1) % len(b.samples)
+	b.count++
+	if b.count%max(1, len(b.samples)/10) != 0 {
+		b.mu.Unlock()
+		return
+	}
+	n := min(b.count, len(b.samples))
+	sorted := make([]time.Duration, n)
+	copy(sorted, b.samples[:n])
+	b.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	p99 := sorted[(n*99-1)/100]
+	b.overloaded.Store(p99 > b.config.P99Threshold)
+}
+
+// MiddlewareBulkhead 为每个路由分别创建舱壁（按 FullPath 区分），适合作为分组中间件使用。
+func MiddlewareBulkhead(config BulkheadConfig) gin.HandlerFunc {
+	var bulkheads sync.Map
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		value, ok := bulkheads.Load(route)
+		if !ok {
+			value, _ = bulkheads.LoadOrStore(route, NewBulkhead(route, config))
+		}
+		value.(*Bulkhead).handle(c)
+	}
+}
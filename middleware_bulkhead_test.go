@@ -0,0 +1,159 @@
+package gb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newBulkheadTestEngine /block 会占住并发额度直到 release 被关闭，entered 在拿到额度后收到通知。
+func newBulkheadTestEngine(b *Bulkhead) (engine *gin.Engine, entered chan struct{}, release chan struct{}) {
+	entered = make(chan struct{}, 8)
+	release = make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	engine = gin.New()
+	engine.GET("/block", b.Middleware(), func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		ResponseSuccess(c, "ok")
+	})
+	engine.GET("/fast", b.Middleware(), func(c *gin.Context) {
+		ResponseSuccess(c, "ok")
+	})
+	return engine, entered, release
+}
+
+func serveBulkhead(engine *gin.Engine, target string) (*httptest.ResponseRecorder, Response) {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// serveBulkheadAsync 在后台发起请求，返回接收结果的 channel。
+func serveBulkheadAsync(engine *gin.Engine, target string) <-chan Response {
+	done := make(chan Response, 1)
+	go func() {
+		_, resp := serveBulkhead(engine, target)
+		done <- resp
+	}()
+	return done
+}
+
+func TestBulkheadShedsWhenFull(t *testing.T) {
+	b := NewBulkhead("shed", BulkheadConfig{MaxConcurrent: 1})
+	engine, entered, release := newBulkheadTestEngine(b)
+
+	first := serveBulkheadAsync(engine, "/block")
+	<-entered
+	if b.InFlight() != 1 {
+		t.Fatalf("in flight = %d, want 1", b.InFlight())
+	}
+	w, resp := serveBulkhead(engine, "/fast")
+	if resp.Code != ErrServerBusy.Code || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("request over limit = %+v, Retry-After %q; want ErrServerBusy", resp, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if resp := <-first; resp.Code != http.StatusOK {
+		t.Fatalf("first request = %+v", resp)
+	}
+	if _, resp := serveBulkhead(engine, "/fast"); resp.Code != http.StatusOK {
+		t.Fatalf("request after release = %+v", resp)
+	}
+}
+
+func TestBulkheadQueue(t *testing.T) {
+	b := NewBulkhead("queue", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1, MaxWait: 30 * time.Millisecond})
+	engine, entered, release := newBulkheadTestEngine(b)
+
+	first := serveBulkheadAsync(engine, "/block")
+	<-entered
+
+	start := time.Now()
+	if _, resp := serveBulkhead(engine, "/fast"); resp.Code != ErrServerBusy.Code {
+		t.Fatalf("queued request = %+v, want ErrServerBusy after MaxWait", resp)
+	}
+	if waited := time.Since(start); waited < 30*time.Millisecond {
+		t.Fatalf("queued request rejected after %s, want to wait MaxWait", waited)
+	}
+
+	// 队列已满时立即拒绝，排队中的请求在额度释放后继续处理
+	b.config.MaxWait = time.Second
+	queued := serveBulkheadAsync(engine, "/fast")
+	for b.waiting.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	start = time.Now()
+	if _, resp := serveBulkhead(engine, "/fast"); resp.Code != ErrServerBusy.Code {
+		t.Fatalf("request over MaxQueue = %+v, want ErrServerBusy", resp)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Fatalf("request over MaxQueue waited %s, want immediate rejection", waited)
+	}
+
+	close(release)
+	if resp := <-first; resp.Code != http.StatusOK {
+		t.Fatalf("first request = %+v", resp)
+	}
+	if resp := <-queued; resp.Code != http.StatusOK {
+		t.Fatalf("queued request = %+v, want it to run once the slot is released", resp)
+	}
+}
+
+func TestBulkheadAdaptiveP99(t *testing.T) {
+	b := NewBulkhead("adaptive", BulkheadConfig{
+		MaxConcurrent:      4,
+		P99Threshold:       20 * time.Millisecond,
+		Window:             10,
+		AdaptiveConcurrent: 1,
+	})
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	// 上游中间件耗时不计入舱壁的 p99
+	engine.Use(func(c *gin.Context) {
+		c.Set("request_time", time.Now().Add(-time.Hour))
+		c.Next()
+	})
+	engine.GET("/fast", b.Middleware(), func(c *gin.Context) {
+		ResponseSuccess(c, "ok")
+	})
+	engine.GET("/slow", b.Middleware(), func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		ResponseSuccess(c, "ok")
+	})
+
+	serveBulkhead(engine, "/fast")
+	if b.Overloaded() {
+		t.Fatal("fast requests marked the bulkhead overloaded")
+	}
+
+	for i := 0; i < 10; i++ {
+		serveBulkhead(engine, "/slow")
+	}
+	if !b.Overloaded() {
+		t.Fatal("slow requests did not mark the bulkhead overloaded")
+	}
+
+	// 过载时并发上限降为 AdaptiveConcurrent
+	slow := serveBulkheadAsync(engine, "/slow")
+	for b.InFlight() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, resp := serveBulkhead(engine, "/fast"); resp.Code != ErrServerBusy.Code {
+		t.Fatalf("request while overloaded = %+v, want ErrServerBusy", resp)
+	}
+	<-slow
+
+	for i := 0; i < 10; i++ {
+		serveBulkhead(engine, "/fast")
+	}
+	if b.Overloaded() {
+		t.Fatal("bulkhead stayed overloaded after latency recovered")
+	}
+}
@@ -0,0 +1,330 @@
+package gb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const responseCachePrefix = "gb:cache"
+
+type ResponseCacheConfig struct {
+	TTL            time.Duration               // 缓存时间
+	Tags           []string                    // 缓存标签，写操作后调用 InvalidateTags 清除
+	VaryByIdentity bool                        // 是否按登录用户区分缓存；为 false 时携带 Authorization 或 Cookie 的请求不走缓存
+	IdentityKey    string                      // JWT 身份键，默认 IdentityKey
+	KeyFunc        func(c *gin.Context) string // 自定义缓存 key，设置后忽略 VaryByIdentity，由调用方保证不同用户的响应不会共用 key
+}
+
+type responseCacheEntry struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+	Body        []byte `json:"body"`
+	RespStatus  int    `json:"resp_status"`
+	RespMessage string `json:"resp_message"`
+}
+
+// MiddlewareETag 为 GET 请求按响应体计算 ETag，命中 If-None-Match 时返回 304。
+// 与 MiddlewareCompress 同时使用时，MiddlewareCompress 需注册在本中间件之前。
+func MiddlewareETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		bw := newBufferedWriter(c.Writer)
+		c.Writer = bw
+		c.Next()
+		c.Writer = bw.ResponseWriter
+		if bw.streaming {
+			return
+		}
+		if bw.Status() == http.StatusOK && bw.Header().Get("ETag") == "" {
+			bw.Header().Set("ETag", computeETag(bw.body.Bytes()))
+		}
+		writeBufferedOrNotModified(c, bw.Status(), bw.body.Bytes())
+	}
+}
+
+// MiddlewareResponseCache 将 GET 请求的成功响应整体缓存到 InsRedis（按请求路径、查询参数与可选的用户区分），
+// 并附带 ETag 支持。未初始化 redis 或请求无法安全缓存时退化为 MiddlewareETag。
+func MiddlewareResponseCache(config ResponseCacheConfig) gin.HandlerFunc {
+	etag := MiddlewareETag()
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || config.TTL <= 0 || InsRedis == nil || InsRedis.UniversalClient == nil {
+			etag(c)
+			return
+		}
+
+		cacheKey, ok := responseCacheKey(c, config)
+		if !ok {
+			etag(c)
+			return
+		}
+		ctx := c.Request.Context()
+		if data, err := InsRedis.Get(ctx, cacheKey).Bytes(); err == nil {
+			var entry responseCacheEntry
+			if err := json.Unmarshal(data, &entry); err == nil {
+				c.Set("resp-status", entry.RespStatus)
+				c.Set("resp-msg", entry.RespMessage)
+				setTraceHeaders(c)
+				c.Header("X-Cache", "HIT")
+				c.Header("ETag", entry.ETag)
+				if entry.ContentType != "" {
+					c.Header("Content-Type", entry.ContentType)
+				}
+				writeBufferedOrNotModified(c, entry.Status, entry.Body)
+				c.Abort()
+				return
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			WriteGinWarnLog(c, "response cache get err: %s", err.Error())
+		}
+
+		bw := newBufferedWriter(c.Writer)
+		c.Writer = bw
+		c.Next()
+		c.Writer = bw.ResponseWriter
+		if bw.streaming {
+			return
+		}
+
+		body := bw.body.Bytes()
+		status := bw.Status()
+		if status == http.StatusOK && bw.Header().Get("ETag") == "" {
+			bw.Header().Set("ETag", computeETag(body))
+		}
+		c.Header("X-Cache", "MISS")
+		// 仅缓存业务成功的响应
+		if status == http.StatusOK && c.GetInt("resp-status") == http.StatusOK {
+			entry := responseCacheEntry{
+				Status:      status,
+				ContentType: bw.Header().Get("Content-Type"),
+				ETag:        bw.Header().Get("ETag"),
+				Body:        append([]byte(nil), body...),
+				RespStatus:  c.GetInt("resp-status"),
+				RespMessage: c.GetString("resp-msg"),
+			}
+			if err := saveResponseCache(ctx, cacheKey, &entry, config); err != nil {
+				WriteGinWarnLog(c, "response cache save err: %s", err.Error())
+			}
+		}
+		writeBufferedOrNotModified(c, status, body)
+	}
+}
+
+// InvalidateTags 清除带有指定标签的全部缓存，缓存保存在 redis 中，因此对所有实例生效。
+func InvalidateTags(ctx context.Context, tags ...string) error {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return redisClientNilErr()
+	}
+	for _, tag := range tags {
+		tagKey := responseCacheTagKey(tag)
+		keys, err := InsRedis.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return err
+		}
+		// 逐个删除，兼容 redis 集群中 key 分布在不同 slot 的情况
+		pipe := InsRedis.Pipeline()
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		pipe.Del(ctx, tagKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveResponseCache 函数用于处理saveResponseCache相关逻辑。
+func saveResponseCache(ctx context.Context, cacheKey string, entry *responseCacheEntry, config ResponseCacheConfig) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	pipe := InsRedis.Pipeline()
+	pipe.Set(ctx, cacheKey, data, config.TTL)
+	for _, tag := range config.Tags {
+		tagKey := responseCacheTagKey(tag)
+		pipe.SAdd(ctx, tagKey, cacheKey)
+		pipe.Expire(ctx, tagKey, config.TTL)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// responseCacheKey 返回缓存 key，返回 false 表示请求不能缓存。
+// key 包含实际请求路径而非路由模板，/dict/:type 的不同 type 分别缓存。
+func responseCacheKey(c *gin.Context, config ResponseCacheConfig) (string, bool) {
+	if config.KeyFunc != nil {
+		return fmt.Sprintf("%s:custom:%s", responseCachePrefix, config.KeyFunc(c)), true
+	}
+	credentials := c.GetHeader("Authorization") != "" || c.GetHeader("Cookie") != ""
+	// url.Values.Encode 按 key 排序，参数顺序不同的请求共享缓存
+	raw := c.Request.URL.Path + "?" + c.Request.URL.Query().Encode()
+	if config.VaryByIdentity {
+		identity := GetIdentity(c, config.IdentityKey)
+		// 携带凭证却取不到身份（如缓存注册在认证中间件之前）时不缓存，避免不同用户共用一份响应
+		if identity == "" && credentials {
+			return "", false
+		}
+		raw += "|" + identity
+	} else if credentials {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return fmt.Sprintf("%s:%s:%s", responseCachePrefix, c.FullPath(), hex.EncodeToString(sum[:16])), true
+}
+
+// responseCacheTagKey 函数用于处理responseCacheTagKey相关逻辑。
+func responseCacheTagKey(tag string) string {
+	return fmt.Sprintf("%s:tag:%s", responseCachePrefix, tag)
+}
+
+// computeETag 函数用于处理computeETag相关逻辑。
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch 判断 If-None-Match 是否命中，按弱比较处理 W/ 前缀。
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBufferedOrNotModified 函数用于处理writeBufferedOrNotModified相关逻辑。
+func writeBufferedOrNotModified(c *gin.Context, status int, body []byte) {
+	if status == http.StatusOK && etagMatch(c.GetHeader("If-None-Match"), c.Writer.Header().Get("ETag")) {
+		header := c.Writer.Header()
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.WriteHeader(status)
+	if c.Request.Method == http.MethodHead || len(body) == 0 {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.Write(body)
+}
+
+// bufferedWriter 缓存完整响应，以便在写出前计算 ETag；处理函数调用 Flush 时切换为直接输出。
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	status    int
+	streaming bool
+}
+
+// newBufferedWriter 函数用于处理newBufferedWriter相关逻辑。
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader 方法用于处理WriteHeader相关逻辑。
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow 方法用于处理WriteHeaderNow相关逻辑。
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write 方法用于处理Write相关逻辑。
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// WriteString 方法用于处理WriteString相关逻辑。
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status 方法用于处理Status相关逻辑。
+func (w *bufferedWriter) Status() int {
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size 方法用于处理Size相关逻辑。
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if w.body.Len() == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written 方法用于处理Written相关逻辑。
+func (w *bufferedWriter) Written() bool {
+	return w.Size() != -1
+}
+
+// Flush 流式响应无法计算 ETag，写出已缓存内容后直接透传。
+func (w *bufferedWriter) Flush() {
+	w.startStreaming()
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 方法用于处理Hijack相关逻辑。
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streaming = true
+	return w.ResponseWriter.Hijack()
+}
+
+// startStreaming 方法用于处理startStreaming相关逻辑。
+func (w *bufferedWriter) startStreaming() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,72 @@
+package gb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseCacheKeyUsesRequestPath(t *testing.T) {
+	setupTestRedis(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/dict/:type", MiddlewareResponseCache(ResponseCacheConfig{TTL: time.Minute}), func(c *gin.Context) {
+		ResponseSuccess(c, c.Param("type"))
+	})
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	first := get("/dict/gender")
+	second := get("/dict/city")
+	if first.Body.String() == second.Body.String() {
+		t.Fatalf("/dict/city served the cached body of /dict/gender: %s", second.Body.String())
+	}
+	if got := get("/dict/city").Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("X-Cache = %q, want HIT", got)
+	}
+}
+
+func TestResponseCacheSkipsCredentialedRequests(t *testing.T) {
+	setupTestRedis(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	identity := func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(IdentityKey, user)
+		}
+	}
+	me := func(c *gin.Context) { ResponseSuccess(c, c.GetHeader("X-User")) }
+	engine.GET("/shared", identity, MiddlewareResponseCache(ResponseCacheConfig{TTL: time.Minute}), me)
+	engine.GET("/me", identity, MiddlewareResponseCache(ResponseCacheConfig{TTL: time.Minute, VaryByIdentity: true}), me)
+
+	get := func(target, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if user != "" {
+			req.Header.Set("Authorization", "Bearer "+user)
+			req.Header.Set("X-User", user)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	get("/shared", "alice")
+	if w := get("/shared", "bob"); w.Header().Get("X-Cache") != "" {
+		t.Fatalf("credentialed request used the shared cache: X-Cache = %q", w.Header().Get("X-Cache"))
+	}
+
+	alice := get("/me", "alice")
+	bob := get("/me", "bob")
+	if alice.Body.String() == bob.Body.String() {
+		t.Fatalf("bob received alice's cached response: %s", bob.Body.String())
+	}
+	if got := get("/me", "bob").Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("X-Cache = %q, want HIT", got)
+	}
+}
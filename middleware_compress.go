@@ -0,0 +1,358 @@
+package gb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+type CompressConfig struct {
+	GzipLevel          int      // gzip 压缩级别，默认 gzip.DefaultCompression
+	BrotliLevel        int      // brotli 压缩级别，默认 brotli.DefaultCompression
+	DisableBrotli      bool     // 是否禁用 brotli
+	MinSize            int      // 响应体小于该值时不压缩，默认 1024 字节
+	ContentTypes       []string // 允许压缩的 Content-Type 前缀，Excel、zip 等已压缩格式不在默认列表中；text/event-stream 始终不压缩
+	ExcludedPaths      []string // 不压缩的路径前缀
+	MaxRequestBodySize int64    // gzip 请求体解压后的最大字节数，默认 10MB，小于 0 表示不解压请求体
+}
+
+// DefaultCompressConfig 默认压缩配置。
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		GzipLevel:   gzip.DefaultCompression,
+		BrotliLevel: brotli.DefaultCompression,
+		MinSize:     1024,
+		ContentTypes: []string{
+			"application/json",
+			"application/javascript",
+			"application/xml",
+			"application/x-www-form-urlencoded",
+			"image/svg+xml",
+			"text/",
+		},
+		MaxRequestBodySize: 10 << 20,
+	}
+}
+
+// MiddlewareCompress 按 Accept-Encoding 协商使用 brotli 或 gzip 压缩响应，并解压 Content-Encoding: gzip 的请求体。
+// 注册在 MiddlewareLogger 之前或之后均可，日志中记录的始终是压缩前的响应体。
+func MiddlewareCompress(config ...CompressConfig) gin.HandlerFunc {
+	cfg := DefaultCompressConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+		def := DefaultCompressConfig()
+		if cfg.GzipLevel == 0 {
+			cfg.GzipLevel = def.GzipLevel
+		}
+		if cfg.BrotliLevel == 0 {
+			cfg.BrotliLevel = def.BrotliLevel
+		}
+		if cfg.MinSize <= 0 {
+			cfg.MinSize = def.MinSize
+		}
+		if len(cfg.ContentTypes) == 0 {
+			cfg.ContentTypes = def.ContentTypes
+		}
+		if cfg.MaxRequestBodySize == 0 {
+			cfg.MaxRequestBodySize = def.MaxRequestBodySize
+		}
+	}
+
+	gzipPool := sync.Pool{New: func() any {
+		w, err := gzip.NewWriterLevel(io.Discard, cfg.GzipLevel)
+		if err != nil {
+			w = gzip.NewWriter(io.Discard)
+		}
+		return w
+	}}
+	brotliPool := sync.Pool{New: func() any {
+		return brotli.NewWriterLevel(io.Discard, cfg.BrotliLevel)
+	}}
+
+	return func(c *gin.Context) {
+		if cfg.MaxRequestBodySize >= 0 && !decompressRequestBody(c, cfg.MaxRequestBodySize) {
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), !cfg.DisableBrotli)
+		if encoding == "" || !shouldCompressRequest(c, cfg.ExcludedPaths) {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{config: &cfg, encoding: encoding, gzipPool: &gzipPool, brotliPool: &brotliPool}
+		// MiddlewareLogger、MiddlewareIdempotency 等已包装 c.Writer 时，把压缩层放到最内层的 ResponseWriter 之下，
+		// 使每一层捕获到的都是明文
+		if lw := innermostResponseWriter(c.Writer); lw != nil {
+			cw.ResponseWriter = lw.ResponseWriter
+			lw.ResponseWriter = cw
+			defer func() {
+				cw.finish()
+				lw.ResponseWriter = cw.ResponseWriter
+			}()
+		} else {
+			cw.ResponseWriter = c.Writer
+			c.Writer = cw
+			defer func() {
+				cw.finish()
+				c.Writer = cw.ResponseWriter
+			}()
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		c.Next()
+	}
+}
+
+// innermostResponseWriter 沿嵌套的 *ResponseWriter 向内查找最内层的一个，c.Writer 不是 *ResponseWriter 时返回 nil。
+func innermostResponseWriter(w gin.ResponseWriter) *ResponseWriter {
+	lw, ok := w.(*ResponseWriter)
+	if !ok {
+		return nil
+	}
+	for {
+		inner, ok := lw.ResponseWriter.(*ResponseWriter)
+		if !ok {
+			return lw
+		}
+		lw = inner
+	}
+}
+
+// decompressRequestBody 解压 gzip 请求体，返回 false 表示请求已被终止。
+func decompressRequestBody(c *gin.Context, limit int64) bool {
+	if c.Request.Body == nil || !strings.EqualFold(strings.TrimSpace(c.GetHeader("Content-Encoding")), "gzip") {
+		return true
+	}
+	reader, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		ResponseError(c, ErrBadRequest.WithMessage("gzip请求体格式错误"))
+		c.Abort()
+		return false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, &gzipRequestBody{Reader: reader, body: c.Request.Body}, limit)
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	return true
+}
+
+type gzipRequestBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Close 方法用于处理Close相关逻辑。
+func (b *gzipRequestBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// shouldCompressRequest 函数用于处理shouldCompressRequest相关逻辑。
+func shouldCompressRequest(c *gin.Context, excludedPaths []string) bool {
+	if c.Request.Method == http.MethodHead || c.GetHeader("Range") != "" {
+		return false
+	}
+	if strings.Contains(strings.ToLower(c.GetHeader("Connection")), "upgrade") || c.GetHeader("Upgrade") != "" {
+		return false
+	}
+	for _, prefix := range excludedPaths {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// negotiateEncoding 按 Accept-Encoding 选择编码，brotli 优先。
+func negotiateEncoding(acceptEncoding string, allowBrotli bool) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if v, err := strconv.ParseFloat(params[2:], 64); err == nil {
+				q = v
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	accept := func(name string) bool {
+		if q, ok := qualities[name]; ok {
+			return q > 0
+		}
+		q, ok := qualities["*"]
+		return ok && q > 0
+	}
+	if allowBrotli && accept("br") {
+		return "br"
+	}
+	if accept("gzip") {
+		return "gzip"
+	}
+	return ""
+}
+
+// compressWriter 先缓存响应，达到 MinSize 后根据 Content-Type 决定是否压缩。
+type compressWriter struct {
+	gin.ResponseWriter
+	config     *CompressConfig
+	encoding   string
+	gzipPool   *sync.Pool
+	brotliPool *sync.Pool
+
+	buf      bytes.Buffer
+	decided  bool
+	encoder  io.WriteCloser
+	finished bool
+}
+
+// Write 方法用于处理Write相关逻辑。
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.config.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString 方法用于处理WriteString相关逻辑。
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 方法用于处理WriteHeaderNow相关逻辑。
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decided = true
+		w.flushBuffer()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Written 方法用于处理Written相关逻辑。
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush 流式响应刷新时立即决定是否压缩。
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 方法用于处理Hijack相关逻辑。
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide 根据状态码与响应头决定是否压缩，并写出已缓存的数据。
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if w.compressible(header) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		switch w.encoding {
+		case "br":
+			bw := w.brotliPool.Get().(*brotli.Writer)
+			bw.Reset(w.ResponseWriter)
+			w.encoder = bw
+		default:
+			gw := w.gzipPool.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.encoder = gw
+		}
+	}
+	return w.flushBuffer()
+}
+
+// compressible 方法用于处理compressible相关逻辑。
+func (w *compressWriter) compressible(header http.Header) bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	// SSE 压缩后会被压缩器缓冲，事件无法及时到达客户端
+	if header.Get("Content-Encoding") != "" || isEventStream(header) {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = strings.ToLower(http.DetectContentType(w.buf.Bytes()))
+	}
+	for _, allowed := range w.config.ContentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// flushBuffer 方法用于处理flushBuffer相关逻辑。
+func (w *compressWriter) flushBuffer() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	data := w.buf.Bytes()
+	w.buf.Reset()
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(data)
+	} else {
+		_, err = w.ResponseWriter.Write(data)
+	}
+	return err
+}
+
+// finish 写出剩余数据并归还压缩器。
+func (w *compressWriter) finish() {
+	if w.finished {
+		return
+	}
+	w.finished = true
+	if !w.decided {
+		// 未达到 MinSize 的响应直接原样输出
+		w.decided = true
+		w.flushBuffer()
+	}
+	if w.encoder == nil {
+		return
+	}
+	w.encoder.Close()
+	switch e := w.encoder.(type) {
+	case *gzip.Writer:
+		e.Reset(io.Discard)
+		w.gzipPool.Put(e)
+	case *brotli.Writer:
+		e.Reset(io.Discard)
+		w.brotliPool.Put(e)
+	}
+	w.encoder = nil
+}
@@ -0,0 +1,122 @@
+package gb
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+var compressTestPayload = map[string]string{"text": strings.Repeat("压缩测试", 1024)}
+
+func TestCompressLoggerSeesPlaintextThroughIdempotency(t *testing.T) {
+	setupTestRedis(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	logs := make(chan ReqLog, 1)
+	engine.Use(MiddlewareLogger(MiddlewareLogConfig{SaveLog: func(l ReqLog) { logs <- l }}))
+	engine.POST("/orders", MiddlewareIdempotency(IdempotencyConfig{}), MiddlewareCompress(), func(c *gin.Context) {
+		ResponseSuccess(c, compressTestPayload)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set(IdempotencyKeyHeader, "compress-1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := w.Header().Values("Vary"); !strings.Contains(strings.Join(got, ","), "Accept-Encoding") {
+		t.Fatalf("Vary = %v, want Accept-Encoding", got)
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := io.ReadAll(reader)
+	if !strings.Contains(string(plain), compressTestPayload["text"]) {
+		t.Fatalf("decompressed body does not contain the payload")
+	}
+
+	saved := <-logs
+	data, ok := saved.Body["data"].(map[string]any)
+	if !ok || data["text"] != compressTestPayload["text"] {
+		t.Fatalf("logger captured a non-plaintext body: %v", saved.Body)
+	}
+
+	// 幂等记录保存的也必须是明文，重放时才能直接返回
+	req = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "compress-1")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	<-logs
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replayed body is not plaintext JSON: %v", err)
+	}
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareCompress())
+	engine.GET("/large", func(c *gin.Context) { ResponseSuccess(c, compressTestPayload) })
+	engine.GET("/small", func(c *gin.Context) { ResponseSuccess(c, "ok") })
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/large", "gzip, br")
+	if got := w.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("Content-Encoding = %q, want br", got)
+	}
+	plain, err := io.ReadAll(brotli.NewReader(w.Body))
+	if err != nil || !strings.Contains(string(plain), compressTestPayload["text"]) {
+		t.Fatalf("brotli body could not be decoded: %v", err)
+	}
+	if w := get("/small", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response below MinSize was compressed")
+	}
+	if w := get("/large", "identity"); w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response was compressed without an accepted encoding")
+	}
+}
+
+func TestCompressSkipsEventStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareCompress())
+	engine.GET("/events", func(c *gin.Context) {
+		s, err := NewSSE(c, SSEConfig{Heartbeat: -1})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer s.Close()
+		s.SendData("progress", strings.Repeat("x", 2048))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("event stream compressed with %q", got)
+	}
+	if !strings.Contains(w.Body.String(), "event: progress") {
+		t.Fatalf("unexpected stream: %q", w.Body.String()[:64])
+	}
+}
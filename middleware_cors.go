@@ -2,31 +2,222 @@ package gb
 
 import (
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Cors 函数用于处理Cors相关逻辑。
+type CorsConfig struct {
+	AllowOrigins     []string                 // 允许的来源，支持精确匹配、"*" 以及 https://*.example.com 形式的通配；AllowCredentials 为 true 时忽略 "*"
+	AllowOriginRegex []string                 // 允许来源的正则表达式
+	AllowOriginFunc  func(origin string) bool // 自定义来源校验
+	AllowMethods     []string                 // 允许的请求方法
+	AllowHeaders     []string                 // 允许的请求头，为空或包含 "*" 时回显预检请求中的请求头
+	ExposeHeaders    []string                 // 允许浏览器读取的响应头
+	AllowCredentials bool                     // 是否允许携带 cookie
+	MaxAge           time.Duration            // 预检请求的缓存时间
+}
+
+// DefaultCorsConfig 默认配置：允许任意来源（Access-Control-Allow-Origin: *），不允许携带 cookie，暴露 Trace-Id。
+// 需要携带 cookie 时应设置 AllowCredentials 并通过 AllowOrigins 白名单或 AllowOriginFunc 指定来源。
+func DefaultCorsConfig() CorsConfig {
+	return CorsConfig{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", TraceIDHeader, "X-Request-Id", "traceparent"},
+		AllowCredentials: false,
+		MaxAge:           24 * time.Hour,
+	}
+}
+
+// Cors 使用 DefaultCorsConfig 的跨域中间件。
 func Cors() gin.HandlerFunc {
+	return CorsWithConfig(DefaultCorsConfig())
+}
+
+// CorsWithConfig 按配置处理跨域请求，匹配的来源会被回显并附带 Vary: Origin。
+func CorsWithConfig(config CorsConfig) gin.HandlerFunc {
+	policy := newCorsPolicy(config)
+	return func(c *gin.Context) {
+		if policy.handle(c) {
+			c.Next()
+		}
+	}
+}
+
+// CorsWithPolicies 按路径前缀为不同路由分组设置跨域策略（最长前缀优先），未匹配的路径使用 defaultConfig。
+// 需注册为全局中间件，以便未注册 OPTIONS 路由的分组也能正确响应预检请求。
+func CorsWithPolicies(policies map[string]CorsConfig, defaultConfig ...CorsConfig) gin.HandlerFunc {
+	prefixes := make([]string, 0, len(policies))
+	compiled := make(map[string]*corsPolicy, len(policies))
+	for prefix, config := range policies {
+		prefixes = append(prefixes, prefix)
+		compiled[prefix] = newCorsPolicy(config)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	var fallback *corsPolicy
+	if len(defaultConfig) > 0 {
+		fallback = newCorsPolicy(defaultConfig[0])
+	}
+
 	return func(c *gin.Context) {
-		// 允许的请求来源
+		policy := fallback
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				policy = compiled[prefix]
+				break
+			}
+		}
+		if policy == nil || policy.handle(c) {
+			c.Next()
+		}
+	}
+}
+
+type corsPolicy struct {
+	config         CorsConfig
+	allowAll       bool
+	exactOrigins   map[string]struct{}
+	wildcards      [][2]string
+	regexps        []*regexp.Regexp
+	echoHeaders    bool
+	allowMethods   string
+	allowHeaders   string
+	exposeHeaders  string
+	maxAge         string
+	allowedMethods map[string]struct{}
+}
+
+// newCorsPolicy 函数用于处理newCorsPolicy相关逻辑。
+func newCorsPolicy(config CorsConfig) *corsPolicy {
+	p := &corsPolicy{
+		config:         config,
+		exactOrigins:   make(map[string]struct{}),
+		allowedMethods: make(map[string]struct{}),
+	}
+	for _, origin := range config.AllowOrigins {
+		switch {
+		case origin == "*":
+			p.allowAll = true
+		case strings.Contains(origin, "*"):
+			idx := strings.Index(origin, "*")
+			p.wildcards = append(p.wildcards, [2]string{strings.ToLower(origin[:idx]), strings.ToLower(origin[idx+1:])})
+		default:
+			p.exactOrigins[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+	for _, expr := range config.AllowOriginRegex {
+		p.regexps = append(p.regexps, regexp.MustCompile(expr))
+	}
+
+	methods := make([]string, 0, len(config.AllowMethods))
+	for _, method := range config.AllowMethods {
+		methods = append(methods, strings.ToUpper(method))
+	}
+	if len(methods) == 0 {
+		methods = DefaultCorsConfig().AllowMethods
+	}
+	for _, method := range methods {
+		p.allowedMethods[method] = struct{}{}
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	p.echoHeaders = len(config.AllowHeaders) == 0
+	for _, header := range config.AllowHeaders {
+		if header == "*" {
+			p.echoHeaders = true
+		}
+	}
+	if !p.echoHeaders {
+		p.allowHeaders = strings.Join(config.AllowHeaders, ", ")
+	}
+	p.exposeHeaders = strings.Join(config.ExposeHeaders, ", ")
+	if config.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+	return p
+}
+
+// allowOrigin 判断来源是否允许。允许携带 cookie 时 "*" 不生效，否则任意站点都能以用户身份发起请求并读取响应。
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allowAll && !p.config.AllowCredentials {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if _, ok := p.exactOrigins[lower]; ok {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		if len(lower) > len(wildcard[0])+len(wildcard[1]) && strings.HasPrefix(lower, wildcard[0]) && strings.HasSuffix(lower, wildcard[1]) {
+			return true
+		}
+	}
+	for _, re := range p.regexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return p.config.AllowOriginFunc != nil && p.config.AllowOriginFunc(origin)
+}
+
+// handle 写入跨域响应头，返回 false 表示请求已被终止。
+func (p *corsPolicy) handle(c *gin.Context) bool {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		return true
+	}
+	c.Writer.Header().Add("Vary", "Origin")
+
+	preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+	if !p.allowOrigin(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	// 允许携带 cookie 时浏览器不接受 "*"，只回显白名单中的具体来源
+	if p.allowAll && !p.config.AllowCredentials {
 		c.Header("Access-Control-Allow-Origin", "*")
-		// 允许的请求方法
-		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		// 允许的请求头
-		c.Header("Access-Control-Allow-Headers", "*")
-		// 允许暴露的响应头
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers")
-		// 是否允许携带 cookie
+	} else {
+		c.Header("Access-Control-Allow-Origin", origin)
+	}
+	if p.config.AllowCredentials {
 		c.Header("Access-Control-Allow-Credentials", "true")
-		// 设置预检请求的缓存时间 1天
-		c.Header("Access-Control-Max-Age", "86400")
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
+	if !preflight {
+		if p.exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", p.exposeHeaders)
 		}
+		return true
+	}
 
-		c.Next()
+	if _, ok := p.allowedMethods[strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))]; !ok {
+		c.AbortWithStatus(http.StatusForbidden)
+		return false
+	}
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+	c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+	c.Header("Access-Control-Allow-Methods", p.allowMethods)
+	if p.echoHeaders {
+		if requestHeaders := c.GetHeader("Access-Control-Request-Headers"); requestHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", requestHeaders)
+		}
+	} else if p.allowHeaders != "" {
+		c.Header("Access-Control-Allow-Headers", p.allowHeaders)
+	}
+	if p.maxAge != "" {
+		c.Header("Access-Control-Max-Age", p.maxAge)
 	}
+	c.AbortWithStatus(http.StatusNoContent)
+	return false
 }
@@ -0,0 +1,80 @@
+package gb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCorsTestEngine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(handler)
+	engine.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	return engine
+}
+
+func corsRequest(engine *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/ping", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestCorsDefaultDoesNotAllowCredentials(t *testing.T) {
+	w := corsRequest(newCorsTestEngine(Cors()), http.MethodGet, "https://evil.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Access-Control-Allow-Credentials = %q, want empty", got)
+	}
+}
+
+func TestCorsWildcardWithCredentialsDoesNotEchoOrigin(t *testing.T) {
+	config := DefaultCorsConfig()
+	config.AllowCredentials = true
+	engine := newCorsTestEngine(CorsWithConfig(config))
+
+	w := corsRequest(engine, http.MethodGet, "https://evil.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want empty", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("Access-Control-Allow-Credentials = %q, want empty", got)
+	}
+
+	w = corsRequest(engine, http.MethodOptions, "https://evil.example")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("preflight status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCorsCredentialsWithAllowlist(t *testing.T) {
+	config := DefaultCorsConfig()
+	config.AllowOrigins = []string{"*", "https://app.example.com"}
+	config.AllowOriginFunc = func(origin string) bool { return origin == "https://admin.example.com" }
+	config.AllowCredentials = true
+	engine := newCorsTestEngine(CorsWithConfig(config))
+
+	for _, origin := range []string{"https://app.example.com", "https://admin.example.com"} {
+		w := corsRequest(engine, http.MethodGet, origin)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, origin)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Fatalf("Access-Control-Allow-Credentials = %q, want true", got)
+		}
+	}
+
+	w := corsRequest(engine, http.MethodGet, "https://evil.example")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want empty", got)
+	}
+}
@@ -0,0 +1,80 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DecryptBodyConfig struct {
+	Custom  func(now int64) (key, nonce string) // 与 EncryptData 相同的密钥回调，参数为请求体中的时间戳
+	KeyRing *KeyRing                            // 密钥环，按请求体中的 kid 选择密钥，设置后忽略 Custom
+	Window  time.Duration                       // 允许的时间戳偏差，默认 5 分钟
+	Methods []string                            // 需要解密的请求方法，默认 POST、PUT、PATCH
+	Now     func() time.Time                    // 当前时间，默认 time.Now
+}
+
+// MiddlewareDecryptBody 解密 EncryptedResponse 格式的请求体，并将明文 JSON 写回请求体，
+// 后续处理函数可直接使用 ShouldBindJSON。
+func MiddlewareDecryptBody(config DecryptBodyConfig) gin.HandlerFunc {
+	if config.Custom == nil && config.KeyRing == nil {
+		panic("DecryptBodyConfig.Custom与KeyRing不能同时为空")
+	}
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	methods := make(map[string]struct{}, len(config.Methods))
+	for _, method := range config.Methods {
+		methods[method] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := methods[c.Request.Method]; !ok || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		var encrypted EncryptedResponse
+		if err := json.NewDecoder(c.Request.Body).Decode(&encrypted); err != nil || encrypted.Data == "" {
+			ResponseError(c, DecryptErr.WithMessage("请求体格式错误"))
+			c.Abort()
+			return
+		}
+
+		if skew := config.Now().Sub(time.Unix(encrypted.Timestamp, 0)); skew > config.Window || skew < -config.Window {
+			ResponseError(c, DecryptErr.WithMessage("请求时间戳已过期"))
+			c.Abort()
+			return
+		}
+
+		var plaintext []byte
+		var err error
+		if config.KeyRing != nil {
+			plaintext, err = config.KeyRing.DecryptBytes(&encrypted)
+		} else {
+			plaintext, err = DecryptDataBytes(&encrypted, config.Custom)
+		}
+		if err != nil {
+			ResponseError(c, DecryptErr)
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(plaintext))
+		c.Request.ContentLength = int64(len(plaintext))
+		c.Request.Header.Set("Content-Length", strconv.Itoa(len(plaintext)))
+		c.Request.Header.Set("Content-Type", gin.MIMEJSON)
+		c.Next()
+	}
+}
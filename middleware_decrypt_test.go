@@ -0,0 +1,87 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testEncryptCustom(int64) (key, nonce string) {
+	return "0123456789abcdef0123456789abcdef", "nonce"
+}
+
+func newDecryptTestEngine(config DecryptBodyConfig, now *time.Time) *gin.Engine {
+	config.Now = func() time.Time { return *now }
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/x", MiddlewareDecryptBody(config), func(c *gin.Context) {
+		var v map[string]int
+		if err := c.ShouldBindJSON(&v); err != nil {
+			ResponseError(c, ErrInvalidParam)
+			return
+		}
+		ResponseSuccess(c, v["a"])
+	})
+	return engine
+}
+
+func postEncrypted(engine *gin.Engine, encrypted *EncryptedResponse) Response {
+	body, _ := json.Marshal(encrypted)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", bytes.NewReader(body)))
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestDecryptBodyCustom(t *testing.T) {
+	now := time.Now()
+	engine := newDecryptTestEngine(DecryptBodyConfig{Custom: testEncryptCustom}, &now)
+
+	encrypted, err := EncryptData(map[string]int{"a": 7}, testEncryptCustom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := postEncrypted(engine, encrypted)
+	if resp.Code != http.StatusOK || resp.Data != float64(7) {
+		t.Fatalf("decrypted request = %+v", resp)
+	}
+
+	now = now.Add(time.Hour)
+	if resp := postEncrypted(engine, encrypted); resp.Code != DecryptErr.Code {
+		t.Fatalf("expired body = %+v, want DecryptErr", resp)
+	}
+}
+
+func TestDecryptBodyCustomRejectsForgedTimestamp(t *testing.T) {
+	now := time.Now()
+	engine := newDecryptTestEngine(DecryptBodyConfig{Custom: testEncryptCustom}, &now)
+	encrypted, _ := EncryptData(map[string]int{"a": 1}, testEncryptCustom)
+
+	// 一小时后重放，并把时间戳改为当前时间以绕过时间窗口
+	now = now.Add(time.Hour)
+	encrypted.Timestamp = now.Unix()
+	if resp := postEncrypted(engine, encrypted); resp.Code != DecryptErr.Code {
+		t.Fatalf("replayed body with forged timestamp = %+v, want DecryptErr", resp)
+	}
+}
+
+func TestDecryptDataRoundTrip(t *testing.T) {
+	encrypted, err := EncryptData(map[string]string{"name": "张三"}, testEncryptCustom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]string
+	if err := DecryptData(encrypted, testEncryptCustom, &v); err != nil || v["name"] != "张三" {
+		t.Fatalf("DecryptData = %v, %v", v, err)
+	}
+	encrypted.Timestamp++
+	if _, err := DecryptDataBytes(encrypted, testEncryptCustom); err == nil {
+		t.Fatal("modified timestamp was accepted")
+	}
+}
@@ -0,0 +1,200 @@
+package gb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type IdempotencyConfig struct {
+	TTL         time.Duration // 响应结果保存时间，默认 24 小时
+	LockTTL     time.Duration // 首个请求处理期间的锁定时间，默认 1 分钟
+	Methods     []string      // 生效的请求方法，默认 POST
+	Required    bool          // 缺少 Idempotency-Key 时是否拒绝请求
+	IdentityKey string        // JWT 身份键，默认 IdentityKey；未认证时按客户端 IP 区分
+	Prefix      string        // redis key 前缀，默认 gb:idempotency
+}
+
+type idempotencyRecord struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	BodyHash    string `json:"body_hash"`
+	RespStatus  int    `json:"resp_status"`
+	RespMessage string `json:"resp_message"`
+}
+
+// MiddlewareIdempotency 幂等中间件：相同用户、路由与 Idempotency-Key 的重复请求直接回放首次的响应。
+func MiddlewareIdempotency(config IdempotencyConfig) gin.HandlerFunc {
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.LockTTL <= 0 {
+		config.LockTTL = time.Minute
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPost}
+	}
+	if config.Prefix == "" {
+		config.Prefix = "gb:idempotency"
+	}
+	methods := make(map[string]struct{}, len(config.Methods))
+	for _, method := range config.Methods {
+		methods[method] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := methods[c.Request.Method]; !ok {
+			c.Next()
+			return
+		}
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			if config.Required {
+				ResponseError(c, ErrInvalidParam.WithMessage("缺少%s请求头", IdempotencyKeyHeader))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if InsRedis == nil || InsRedis.UniversalClient == nil {
+			WriteGinWarnLog(c, "idempotency skipped: %s", redisClientNilErr().Error())
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				ResponseError(c, ErrBadRequest.WithMessage("读取请求体失败"))
+				c.Abort()
+				return
+			}
+			requestBody = body
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		sum := sha256.Sum256(requestBody)
+		bodyHash := hex.EncodeToString(sum[:])
+
+		scope := GetIdentity(c, config.IdentityKey)
+		if scope == "" {
+			scope = "ip:" + c.ClientIP()
+		}
+		redisKey := fmt.Sprintf("%s:%s:%s:%s:%s", config.Prefix, scope, c.Request.Method, c.FullPath(), idempotencyKey)
+		lockKey := redisKey + ":lock"
+		ctx := c.Request.Context()
+
+		// 已有结果：请求体一致则回放，否则视为冲突
+		if replayed, ok := replayIdempotency(c, redisKey, bodyHash); !ok || replayed {
+			return
+		}
+
+		// 锁的值为 "token:bodyHash"，token 用于只释放自己持有的锁，bodyHash 用于判断并发请求是否冲突
+		lockValue := xid.New().String() + ":" + bodyHash
+		locked, err := InsRedis.SetNX(ctx, lockKey, lockValue, config.LockTTL).Result()
+		if err != nil {
+			ResponseError(c, ErrRedis.WithMessage(err.Error()))
+			c.Abort()
+			return
+		}
+		if !locked {
+			if value, _ := InsRedis.Get(ctx, lockKey).Result(); value != "" && !strings.HasSuffix(value, ":"+bodyHash) {
+				ResponseError(c, ErrIdempotencyConflict)
+			} else {
+				ResponseError(c, ErrIdempotencyProcessing)
+			}
+			c.Abort()
+			return
+		}
+		// 锁超时后可能已被其他请求持有，使用比较后删除的脚本释放，避免删除他人的锁
+		defer func() {
+			if _, err := InsRedis.LuaRedisDistributedUnlock(lockKey, lockValue); err != nil {
+				WriteGinWarnLog(c, "release idempotency lock err: %s", err.Error())
+			}
+		}()
+		// 加锁前读取结果与加锁之间，首个请求可能已保存结果并释放锁，加锁后需再次检查，否则处理函数会执行两次
+		if replayed, ok := replayIdempotency(c, redisKey, bodyHash); !ok || replayed {
+			return
+		}
+		// 客户端断开后请求 context 会被取消，保存结果需使用不会取消的 context，
+		// 否则结果丢失，之后的重试会再次执行处理函数
+		persistCtx := context.WithoutCancel(ctx)
+
+		// 与 MiddlewareLogger 相同的 ResponseWriter 包装，用于捕获响应
+		writer := c.Writer
+		bodyBuffer := newLimitedBuffer(0)
+		c.Writer = &ResponseWriter{ResponseWriter: writer, body: bodyBuffer}
+		c.Next()
+		c.Writer = writer
+
+		// 服务端错误不保存，允许客户端重试
+		respStatus := c.GetInt("resp-status")
+		if c.Writer.Status() >= http.StatusInternalServerError || respStatus >= ErrServerBusy.Code {
+			return
+		}
+		data, err := json.Marshal(&idempotencyRecord{
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        bodyBuffer.Bytes(),
+			BodyHash:    bodyHash,
+			RespStatus:  respStatus,
+			RespMessage: c.GetString("resp-msg"),
+		})
+		if err != nil {
+			return
+		}
+		if err := InsRedis.Set(persistCtx, redisKey, data, config.TTL).Err(); err != nil {
+			WriteGinWarnLog(c, "save idempotency record err: %s", err.Error())
+		}
+	}
+}
+
+// replayIdempotency 读取已保存的结果并回放，replayed 表示已回放或已判定冲突；ok 为 false 表示 redis 出错，已返回错误响应。
+func replayIdempotency(c *gin.Context, redisKey, bodyHash string) (replayed bool, ok bool) {
+	data, err := InsRedis.Get(c.Request.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, true
+	}
+	if err != nil {
+		ResponseError(c, ErrRedis.WithMessage(err.Error()))
+		c.Abort()
+		return false, false
+	}
+	var record idempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return false, true
+	}
+	if record.BodyHash != bodyHash {
+		ResponseError(c, ErrIdempotencyConflict)
+		c.Abort()
+		return true, true
+	}
+	replayIdempotencyRecord(c, &record)
+	return true, true
+}
+
+// replayIdempotencyRecord 函数用于处理replayIdempotencyRecord相关逻辑。
+func replayIdempotencyRecord(c *gin.Context, record *idempotencyRecord) {
+	c.Set("resp-status", record.RespStatus)
+	c.Set("resp-msg", record.RespMessage)
+	setTraceHeaders(c)
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
@@ -0,0 +1,155 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// setNXHook 在 SET NX 执行前调用 before，用于模拟并发请求在读取结果与加锁之间完成。
+type setNXHook struct{ before func() }
+
+func (h setNXHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h setNXHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if args := cmd.Args(); h.before != nil && cmd.Name() == "set" && len(args) > 3 && args[len(args)-1] == "nx" {
+			h.before()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h setNXHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func newIdempotencyTestEngine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/orders", MiddlewareIdempotency(IdempotencyConfig{}), handler)
+	return engine
+}
+
+func postIdempotent(engine *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyRechecksRecordAfterLock(t *testing.T) {
+	m := setupTestRedis(t)
+	calls := 0
+	engine := newIdempotencyTestEngine(func(c *gin.Context) {
+		calls++
+		ResponseSuccess(c, calls)
+	})
+
+	postIdempotent(engine, "order-1", `{"sku":1}`)
+	const recordKey = "gb:idempotency:ip:192.0.2.1:POST:/orders:order-1"
+	record, err := m.Get(recordKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟重试在首个请求保存结果前读取（未命中），首个请求随后保存结果并释放锁，重试再加锁成功
+	m.Del(recordKey)
+	var once bool
+	InsRedis.AddHook(setNXHook{before: func() {
+		if !once {
+			once = true
+			m.Set(recordKey, record)
+		}
+	}})
+
+	w := postIdempotent(engine, "order-1", `{"sku":1}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry was not replayed: %s", w.Body.String())
+	}
+}
+
+func TestIdempotencyKeepsLockTakenOverByAnotherRequest(t *testing.T) {
+	m := setupTestRedis(t)
+	const lockKey = "gb:idempotency:ip:192.0.2.1:POST:/orders:order-2:lock"
+	engine := newIdempotencyTestEngine(func(c *gin.Context) {
+		// 模拟处理超过 LockTTL，锁过期后被另一个请求获取
+		m.Set(lockKey, "other:1")
+		ResponseSuccess(c, nil)
+	})
+	postIdempotent(engine, "order-2", `{"sku":2}`)
+	if got, err := m.Get(lockKey); err != nil || got != "other:1" {
+		t.Fatalf("lock = %q, %v; the expired owner deleted another request's lock", got, err)
+	}
+}
+
+func TestIdempotencyConcurrentRequests(t *testing.T) {
+	setupTestRedis(t)
+	var inner *httptest.ResponseRecorder
+	var engine *gin.Engine
+	engine = newIdempotencyTestEngine(func(c *gin.Context) {
+		if inner == nil {
+			inner = postIdempotent(engine, "order-3", `{"sku":3}`)
+		}
+		ResponseSuccess(c, nil)
+	})
+	postIdempotent(engine, "order-3", `{"sku":3}`)
+	var resp Response
+	if err := json.Unmarshal(inner.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != ErrIdempotencyProcessing.Code {
+		t.Fatalf("concurrent retry got code %d, want %d", resp.Code, ErrIdempotencyProcessing.Code)
+	}
+}
+
+func TestIdempotencySavesRecordAfterClientDisconnect(t *testing.T) {
+	m := setupTestRedis(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	calls := 0
+	var disconnect context.CancelFunc
+	engine.POST("/orders", MiddlewareIdempotency(IdempotencyConfig{}), func(c *gin.Context) {
+		calls++
+		// 模拟客户端在处理期间断开
+		if disconnect != nil {
+			disconnect()
+		}
+		ResponseSuccess(c, calls)
+	})
+
+	post := func(ctx context.Context) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"sku":1}`)).WithContext(ctx)
+		req.Header.Set(IdempotencyKeyHeader, "order-1")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	disconnect = cancel
+	post(ctx)
+	disconnect = nil
+
+	for _, key := range m.Keys() {
+		if strings.HasSuffix(key, ":lock") {
+			t.Fatalf("lock %s was not released after client disconnect", key)
+		}
+	}
+	w := post(context.Background())
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry was not replayed: %s", w.Body.String())
+	}
+}
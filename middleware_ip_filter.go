@@ -0,0 +1,178 @@
+package gb
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IPFilterConfig struct {
+	Allow          []string      // 允许的 IP 或 CIDR，非空时仅允许列表中的地址；配置了 Allow 或 RedisAllowKey 但列表为空时拒绝全部地址
+	Deny           []string      // 拒绝的 IP 或 CIDR，优先于 Allow
+	RedisAllowKey  string        // 存放允许列表的 redis set，与 Allow 合并
+	RedisDenyKey   string        // 存放拒绝列表的 redis set，与 Deny 合并
+	ReloadInterval time.Duration // 从 redis 重新加载的间隔，默认 30 秒
+}
+
+type ipFilterLists struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// IPFilter 按 c.ClientIP() 做 CIDR 白名单/黑名单校验，可挂在路由分组上（如仅允许办公网访问后台接口）。
+// 客户端 IP 依赖 WithGinTrustedProxies 配置，否则经过代理的请求拿到的是代理地址。
+type IPFilter struct {
+	config     IPFilterConfig
+	lists      atomic.Pointer[ipFilterLists]
+	lastReload atomic.Int64
+	reloading  atomic.Bool
+}
+
+// NewIPFilter 创建 IP 过滤器，配置了 redis key 时会立即加载一次。
+func NewIPFilter(config IPFilterConfig) (*IPFilter, error) {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = 30 * time.Second
+	}
+	f := &IPFilter{config: config}
+	if err := f.SetLists(config.Allow, config.Deny); err != nil {
+		return nil, err
+	}
+	if f.usesRedis() {
+		if err := f.Reload(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Middleware 返回 IP 过滤中间件，不允许的地址返回 ErrIPForbidden。
+func (f *IPFilter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f.maybeReload()
+		if !f.Allowed(c.ClientIP()) {
+			ResponseError(c, ErrIPForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allowed 判断 IP 是否允许访问。
+func (f *IPFilter) Allowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	lists := f.lists.Load()
+	for _, prefix := range lists.deny {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(lists.allow) == 0 {
+		// 配置了白名单来源但解析结果为空（如 redis set 不存在或被清空）时拒绝，避免后台接口对所有地址开放
+		return !f.allowConfigured()
+	}
+	for _, prefix := range lists.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLists 替换静态列表之外的全部规则，传入的列表会与配置中的 Allow/Deny 合并。
+func (f *IPFilter) SetLists(allow, deny []string) error {
+	allowPrefixes, err := parseIPPrefixes(append(append([]string{}, f.config.Allow...), allow...))
+	if err != nil {
+		return err
+	}
+	denyPrefixes, err := parseIPPrefixes(append(append([]string{}, f.config.Deny...), deny...))
+	if err != nil {
+		return err
+	}
+	f.lists.Store(&ipFilterLists{allow: allowPrefixes, deny: denyPrefixes})
+	return nil
+}
+
+// Reload 从 redis set 重新加载列表。
+func (f *IPFilter) Reload(ctx context.Context) error {
+	f.lastReload.Store(time.Now().UnixNano())
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return redisClientNilErr()
+	}
+	var allow, deny []string
+	var err error
+	if f.config.RedisAllowKey != "" {
+		if allow, err = InsRedis.SMembers(ctx, f.config.RedisAllowKey).Result(); err != nil {
+			return err
+		}
+	}
+	if f.config.RedisDenyKey != "" {
+		if deny, err = InsRedis.SMembers(ctx, f.config.RedisDenyKey).Result(); err != nil {
+			return err
+		}
+	}
+	return f.SetLists(allow, deny)
+}
+
+// allowConfigured 是否配置了白名单来源。
+func (f *IPFilter) allowConfigured() bool {
+	return len(f.config.Allow) > 0 || f.config.RedisAllowKey != ""
+}
+
+// usesRedis 方法用于处理usesRedis相关逻辑。
+func (f *IPFilter) usesRedis() bool {
+	return f.config.RedisAllowKey != "" || f.config.RedisDenyKey != ""
+}
+
+// maybeReload 到达重新加载间隔后在后台刷新列表，刷新失败时继续使用旧列表。
+func (f *IPFilter) maybeReload() {
+	if !f.usesRedis() || time.Since(time.Unix(0, f.lastReload.Load())) < f.config.ReloadInterval {
+		return
+	}
+	if !f.reloading.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer f.reloading.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := f.Reload(ctx); err != nil {
+			zlog.Warn().Err(err).Msg("ip filter reload failed")
+		}
+	}()
+}
+
+// parseIPPrefixes 解析 IP 或 CIDR 列表。
+func parseIPPrefixes(items []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("无效的CIDR %s: %w", item, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("无效的IP %s: %w", item, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,60 @@
+package gb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIPFilterStaticLists(t *testing.T) {
+	f, err := NewIPFilter(IPFilterConfig{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.2.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"10.2.0.1":        true,
+		"10.1.2.3":        false,
+		"8.8.8.8":         false,
+		"::ffff:10.2.0.1": true,
+		"invalid":         false,
+	}
+	for ip, want := range cases {
+		if got := f.Allowed(ip); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	open, err := NewIPFilter(IPFilterConfig{Deny: []string{"10.1.2.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !open.Allowed("8.8.8.8") || open.Allowed("10.1.2.3") {
+		t.Fatal("deny-only filter should allow every address except the denied ones")
+	}
+}
+
+func TestIPFilterEmptyRedisAllowListDeniesAll(t *testing.T) {
+	m := setupTestRedis(t)
+	f, err := NewIPFilter(IPFilterConfig{RedisAllowKey: "gb:office"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Allowed("8.8.8.8") {
+		t.Fatal("missing redis allowlist allowed every address")
+	}
+
+	m.SAdd("gb:office", "10.0.0.0/8")
+	if err := f.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Allowed("10.2.0.1") || f.Allowed("8.8.8.8") {
+		t.Fatal("redis allowlist was not applied")
+	}
+
+	m.Del("gb:office")
+	if err := f.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if f.Allowed("10.2.0.1") {
+		t.Fatal("cleared redis allowlist allowed every address")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // LogEntry 表示一个日志条目
@@ -74,6 +75,9 @@ func (rl *RequestLogger) Flush() {
 
 	// 构建合并的日志事件
 	event := rl.logger.Info()
+	if sc := trace.SpanContextFromContext(rl.ctx); sc.IsValid() {
+		event = event.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+	}
 
 	// 添加所有收集的日志条目
 	logEntries := make([]map[string]any, 0, len(rl.entries))
@@ -218,6 +222,7 @@ type ResponseWriter struct {
 type limitedBuffer struct {
 	limit     int
 	truncated bool
+	skipped   bool // 流式响应（text/event-stream）不记录响应体
 	buf       bytes.Buffer
 }
 
@@ -270,7 +275,7 @@ func (b *limitedBuffer) Truncated() bool {
 // Write 方法用于处理Write相关逻辑。
 func (w ResponseWriter) Write(b []byte) (int, error) {
 	// 写入到缓冲区
-	w.body.Write(b)
+	w.capture(b)
 	// 继续原始的写入操作
 	return w.ResponseWriter.Write(b)
 }
@@ -278,11 +283,25 @@ func (w ResponseWriter) Write(b []byte) (int, error) {
 // WriteString 方法用于处理WriteString相关逻辑。
 func (w ResponseWriter) WriteString(s string) (int, error) {
 	// 写入到缓冲区
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	// 继续原始的写入操作
 	return w.ResponseWriter.WriteString(s)
 }
 
+// capture 记录响应体，SSE 等流式响应不记录。
+func (w ResponseWriter) capture(b []byte) {
+	if w.body.skipped || isEventStream(w.Header()) {
+		w.body.skipped = true
+		return
+	}
+	w.body.Write(b)
+}
+
+// Unwrap 供 http.ResponseController 访问底层连接。
+func (w ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 var zlog zerolog.Logger
 
 // init 函数用于处理init相关逻辑。
@@ -312,6 +331,7 @@ type ReqLog struct {
 	Body        map[string]any    `json:"body,omitempty"`
 	RespStatus  int               `json:"resp_status"`  // 响应数据中的状态码
 	RespMessage string            `json:"resp_message"` // 响应数据中的message
+	TraceID     string            `json:"trace_id,omitempty"`
 }
 
 type MiddlewareLogConfig struct {
@@ -454,6 +474,9 @@ func MiddlewareLogger(mc MiddlewareLogConfig) gin.HandlerFunc {
 			} else {
 				recordBodySkip(params, reason)
 			}
+		} else if strings.Contains(contentType, "octet-stream") {
+			// 二进制数据（如断点续传分片）不记录
+			recordBodySkip(params, "binary request body not captured")
 		} else {
 			if ok, reason := shouldCaptureRequestBody(c.Request); ok {
 				if requestBody, err := io.ReadAll(c.Request.Body); err == nil {
@@ -528,6 +551,9 @@ func MiddlewareLogger(mc MiddlewareLogConfig) gin.HandlerFunc {
 					bodyMap["raw"] = string(data)
 				}
 			}
+			if bodyBuffer.skipped {
+				bodyMap["resp_skipped"] = "streaming response body not captured"
+			}
 			if bodyBuffer.Truncated() {
 				bodyMap["resp_truncated"] = fmt.Sprintf("response body exceeded %d bytes and was truncated", maxLoggedBodyBytes)
 			}
@@ -561,6 +587,7 @@ func MiddlewareLogger(mc MiddlewareLogConfig) gin.HandlerFunc {
 				Body:        bodyMap,
 				RespStatus:  c.GetInt("resp-status"),
 				RespMessage: c.GetString("resp-msg"),
+				TraceID:     c.GetString("trace_id"),
 			})
 		}
 	}
@@ -582,22 +609,22 @@ func GetContextLogger(c *gin.Context) *ContextLogger {
 
 // WriteGinInfoLog 函数用于处理WriteGinInfoLog相关逻辑。
 func WriteGinInfoLog(c *gin.Context, format string, args ...any) {
-	GetContextLogger(c).Info().Msgf(format, args)
+	GetContextLogger(c).Info().Msgf(format, args...)
 }
 
 // WriteGinDebugLog 函数用于处理WriteGinDebugLog相关逻辑。
 func WriteGinDebugLog(c *gin.Context, format string, args ...any) {
-	GetContextLogger(c).Debug().Msgf(format, args)
+	GetContextLogger(c).Debug().Msgf(format, args...)
 }
 
 // WriteGinWarnLog 函数用于处理WriteGinWarnLog相关逻辑。
 func WriteGinWarnLog(c *gin.Context, format string, args ...any) {
-	GetContextLogger(c).Warn().Msgf(format, args)
+	GetContextLogger(c).Warn().Msgf(format, args...)
 }
 
 // WriteGinErrLog 函数用于处理WriteGinErrLog相关逻辑。
 func WriteGinErrLog(c *gin.Context, format string, args ...any) {
-	GetContextLogger(c).Error().Msgf(format, args)
+	GetContextLogger(c).Error().Msgf(format, args...)
 }
 
 // GinLogSetModuleName 函数用于处理GinLogSetModuleName相关逻辑。
@@ -0,0 +1,247 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type MaintenanceMode string
+
+const (
+	MaintenanceOff      MaintenanceMode = ""          // 正常服务
+	MaintenanceReadOnly MaintenanceMode = "read_only" // 只读：仅放行 GET、HEAD、OPTIONS
+	MaintenanceFull     MaintenanceMode = "full"      // 维护：拒绝全部请求
+)
+
+// valid 判断是否为已知的维护模式。
+func (mode MaintenanceMode) valid() bool {
+	switch mode {
+	case MaintenanceOff, MaintenanceReadOnly, MaintenanceFull:
+		return true
+	}
+	return false
+}
+
+type MaintenanceConfig struct {
+	RedisKey         string                    // 保存维护模式的 redis key，多实例共享；为空时仅使用进程内开关
+	RefreshInterval  time.Duration             // 从 redis 刷新模式的间隔，默认 5 秒
+	Error            *AppError                 // 维护模式返回的错误，默认 ErrMaintenance
+	ReadOnlyError    *AppError                 // 只读模式返回的错误，默认 ErrReadOnlyMode
+	RetryAfter       time.Duration             // Retry-After 响应头，默认 5 分钟
+	BypassIdentities []string                  // 可绕过的 JWT 身份（需注册在认证中间件之后才能取到身份）
+	IdentityKey      string                    // JWT 身份键，默认 IdentityKey
+	BypassCIDRs      []string                  // 可绕过的 IP 或 CIDR
+	BypassPaths      []string                  // 可绕过的路径前缀
+	ProbePaths       []string                  // 始终放行的探针路由，与 c.FullPath() 完全匹配；通过 WithGinMaintenance 挂载时自动包含该服务注册的 /healthz、/livez、/readyz
+	BypassFunc       func(c *gin.Context) bool // 自定义绕过规则
+}
+
+// Maintenance 维护模式开关，可通过 SetMode 在进程内切换，或通过 SetRedisMode 让所有实例同时生效。
+type Maintenance struct {
+	config      MaintenanceConfig
+	local       atomic.Value // MaintenanceMode
+	remote      atomic.Value // MaintenanceMode
+	lastRefresh atomic.Int64
+	refreshing  atomic.Bool
+	bypassIPs   *IPFilter
+	identities  map[string]struct{}
+	probes      map[string]struct{}
+}
+
+// NewMaintenance 创建维护模式开关。
+func NewMaintenance(config MaintenanceConfig) (*Maintenance, error) {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 5 * time.Second
+	}
+	if config.Error == nil {
+		config.Error = ErrMaintenance
+	}
+	if config.ReadOnlyError == nil {
+		config.ReadOnlyError = ErrReadOnlyMode
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = 5 * time.Minute
+	}
+	m := &Maintenance{
+		config:     config,
+		identities: make(map[string]struct{}, len(config.BypassIdentities)),
+		probes:     make(map[string]struct{}, len(config.ProbePaths)),
+	}
+	for _, probe := range config.ProbePaths {
+		m.probes[probe] = struct{}{}
+	}
+	m.local.Store(MaintenanceOff)
+	m.remote.Store(MaintenanceOff)
+	for _, identity := range config.BypassIdentities {
+		m.identities[identity] = struct{}{}
+	}
+	if len(config.BypassCIDRs) > 0 {
+		bypass, err := NewIPFilter(IPFilterConfig{Allow: config.BypassCIDRs})
+		if err != nil {
+			return nil, err
+		}
+		m.bypassIPs = bypass
+	}
+	if config.RedisKey != "" && InsRedis != nil && InsRedis.UniversalClient != nil {
+		if err := m.Refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// SetMode 设置进程内维护模式，仅对当前实例生效。
+func (m *Maintenance) SetMode(mode MaintenanceMode) error {
+	if !mode.valid() {
+		return fmt.Errorf("未知的维护模式: %q", mode)
+	}
+	m.local.Store(mode)
+	return nil
+}
+
+// SetRedisMode 设置 redis 中的维护模式，ttl 大于 0 时到期自动恢复，所有实例在 RefreshInterval 内生效。
+func (m *Maintenance) SetRedisMode(ctx context.Context, mode MaintenanceMode, ttl time.Duration) error {
+	if m.config.RedisKey == "" {
+		return errors.New("MaintenanceConfig.RedisKey为空")
+	}
+	if !mode.valid() {
+		return fmt.Errorf("未知的维护模式: %q", mode)
+	}
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return redisClientNilErr()
+	}
+	var err error
+	if mode == MaintenanceOff {
+		err = InsRedis.Del(ctx, m.config.RedisKey).Err()
+	} else {
+		err = InsRedis.Set(ctx, m.config.RedisKey, string(mode), ttl).Err()
+	}
+	if err != nil {
+		return err
+	}
+	m.remote.Store(mode)
+	m.lastRefresh.Store(time.Now().UnixNano())
+	return nil
+}
+
+// Mode 返回当前生效的模式，进程内开关与 redis 取更严格的一个。
+func (m *Maintenance) Mode() MaintenanceMode {
+	local, remote := m.local.Load().(MaintenanceMode), m.remote.Load().(MaintenanceMode)
+	if local == MaintenanceFull || remote == MaintenanceFull {
+		return MaintenanceFull
+	}
+	if local == MaintenanceReadOnly || remote == MaintenanceReadOnly {
+		return MaintenanceReadOnly
+	}
+	return MaintenanceOff
+}
+
+// Refresh 从 redis 读取维护模式。
+func (m *Maintenance) Refresh(ctx context.Context) error {
+	m.lastRefresh.Store(time.Now().UnixNano())
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return redisClientNilErr()
+	}
+	value, err := InsRedis.Get(ctx, m.config.RedisKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	mode := MaintenanceMode(value)
+	if !mode.valid() {
+		// 无法识别的值可能是误写，保持上一次的模式，避免意外解除维护
+		zlog.Warn().Str("key", m.config.RedisKey).Str("mode", value).Msg("unknown maintenance mode, keep last mode")
+		return nil
+	}
+	m.remote.Store(mode)
+	return nil
+}
+
+// Middleware 返回维护模式中间件，仅放行 ProbePaths 中的探针；挂载到 HTTPServer 时使用 WithGinMaintenance 以放行其探针。
+func (m *Maintenance) Middleware() gin.HandlerFunc {
+	return m.middleware(nil)
+}
+
+// middleware 返回维护模式中间件，probes 为所挂载服务的探针路由，仅对该服务生效。
+func (m *Maintenance) middleware(probes []string) gin.HandlerFunc {
+	serverProbes := make(map[string]struct{}, len(probes))
+	for _, probe := range probes {
+		serverProbes[probe] = struct{}{}
+	}
+	return func(c *gin.Context) {
+		m.maybeRefresh()
+		mode := m.Mode()
+		if mode == MaintenanceOff || m.bypass(c, serverProbes) {
+			c.Next()
+			return
+		}
+		if mode == MaintenanceReadOnly {
+			switch c.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				c.Next()
+				return
+			}
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(m.config.RetryAfter.Seconds())))
+		if mode == MaintenanceReadOnly {
+			ResponseError(c, m.config.ReadOnlyError)
+		} else {
+			ResponseError(c, m.config.Error)
+		}
+		c.Abort()
+	}
+}
+
+// bypass 方法用于处理bypass相关逻辑。
+func (m *Maintenance) bypass(c *gin.Context, serverProbes map[string]struct{}) bool {
+	// 按路由模板完全匹配探针，避免 /orders/:id 这类以参数结尾的路由被当作探针放行
+	if fullPath := c.FullPath(); fullPath != "" {
+		if _, ok := m.probes[fullPath]; ok {
+			return true
+		}
+		if _, ok := serverProbes[fullPath]; ok {
+			return true
+		}
+	}
+	for _, prefix := range m.config.BypassPaths {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return true
+		}
+	}
+	if m.bypassIPs != nil && m.bypassIPs.Allowed(c.ClientIP()) {
+		return true
+	}
+	if len(m.identities) > 0 {
+		if _, ok := m.identities[GetIdentity(c, m.config.IdentityKey)]; ok {
+			return true
+		}
+	}
+	return m.config.BypassFunc != nil && m.config.BypassFunc(c)
+}
+
+// maybeRefresh 到达刷新间隔后在后台读取 redis，读取失败时保持原模式。
+func (m *Maintenance) maybeRefresh() {
+	if m.config.RedisKey == "" || time.Since(time.Unix(0, m.lastRefresh.Load())) < m.config.RefreshInterval {
+		return
+	}
+	if !m.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer m.refreshing.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := m.Refresh(ctx); err != nil {
+			zlog.Warn().Err(err).Msg("maintenance mode refresh failed")
+		}
+	}()
+}
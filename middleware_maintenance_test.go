@@ -0,0 +1,126 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newMaintenanceTestEngine(m *Maintenance) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(m.Middleware())
+	calls := new(int)
+	handler := func(c *gin.Context) {
+		*calls++
+		c.Status(http.StatusOK)
+	}
+	engine.GET("/healthz", handler)
+	engine.DELETE("/api/orders/:id", handler)
+	engine.POST("/api/orders", handler)
+	return engine, calls
+}
+
+func TestMaintenanceProbeBypassUsesRoute(t *testing.T) {
+	m, err := NewMaintenance(MaintenanceConfig{ProbePaths: []string{"/healthz"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetMode(MaintenanceFull); err != nil {
+		t.Fatal(err)
+	}
+	engine, calls := newMaintenanceTestEngine(m)
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if *calls != 1 {
+		t.Fatalf("probe was blocked in maintenance mode")
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/orders/healthz", nil))
+	if *calls != 1 {
+		t.Fatalf("DELETE /api/orders/healthz bypassed maintenance mode")
+	}
+}
+
+func TestMaintenanceUnknownRedisModeKeepsLastMode(t *testing.T) {
+	redis := setupTestRedis(t)
+	m, err := NewMaintenance(MaintenanceConfig{RedisKey: "gb:maintenance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetRedisMode(context.Background(), MaintenanceFull, 0); err != nil {
+		t.Fatal(err)
+	}
+	redis.Set("gb:maintenance", "ful")
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if mode := m.Mode(); mode != MaintenanceFull {
+		t.Fatalf("Mode() = %q, want %q", mode, MaintenanceFull)
+	}
+	engine, calls := newMaintenanceTestEngine(m)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/orders", nil))
+	if *calls != 0 {
+		t.Fatal("request passed while maintenance mode is full")
+	}
+
+	if err := m.SetRedisMode(context.Background(), MaintenanceMode("ful"), 0); err == nil {
+		t.Fatal("SetRedisMode accepted an unknown mode")
+	}
+}
+
+func TestMaintenanceServerProbesAreScopedToServer(t *testing.T) {
+	m, err := NewMaintenance(MaintenanceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetMode(MaintenanceFull); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter("/api")
+	router.Public(func(group *gin.RouterGroup) {
+		group.POST("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	})
+	server := NewHTTPServer("127.0.0.1:0", WithGinRouterModel(GinModelTest), WithGinSkipLog(true),
+		WithGinRouters(router), WithGinMaintenance(m))
+
+	serve := func(engine *gin.Engine, method, target string) int {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		var resp Response
+		if json.Unmarshal(w.Body.Bytes(), &resp) == nil && resp.Code != 0 {
+			return resp.Code
+		}
+		return w.Code
+	}
+	if code := serve(server.Engine(), http.MethodGet, "/api/healthz"); code != http.StatusOK {
+		t.Fatalf("probe of the server got %d in maintenance mode", code)
+	}
+	if code := serve(server.Engine(), http.MethodPost, "/api/orders"); code != ErrMaintenance.Code {
+		t.Fatalf("POST /api/orders got %d, want %d", code, ErrMaintenance.Code)
+	}
+
+	// 另一个引擎上的同名路由不是该服务的探针，不能被放行
+	other, calls := newMaintenanceTestEngine(m)
+	other.GET("/api/healthz", func(c *gin.Context) { *calls++ })
+	other.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/healthz", nil))
+	if *calls != 0 {
+		t.Fatal("probe path of one server bypassed maintenance on another engine")
+	}
+}
+
+func TestMaintenanceSetModeRejectsUnknownMode(t *testing.T) {
+	m, err := NewMaintenance(MaintenanceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetMode(MaintenanceMode("ful")); err == nil {
+		t.Fatal("SetMode accepted an unknown mode")
+	}
+	if mode := m.Mode(); mode != MaintenanceOff {
+		t.Fatalf("Mode() = %q after rejected SetMode", mode)
+	}
+}
@@ -0,0 +1,350 @@
+package gb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type RateLimitAlgorithm string
+
+const (
+	RateLimitGCRA             RateLimitAlgorithm = "gcra"               // 通用信元速率算法，平滑且只需存储一个时间戳
+	RateLimitTokenBucket      RateLimitAlgorithm = "token_bucket"       // 令牌桶
+	RateLimitSlidingWindowLog RateLimitAlgorithm = "sliding_window_log" // 滑动窗口日志，精确但占用内存与请求数成正比
+)
+
+// RateLimitKeyFunc 返回限流维度的 key，返回空字符串表示不限流。
+type RateLimitKeyFunc func(c *gin.Context) string
+
+type RateLimitConfig struct {
+	Algorithm RateLimitAlgorithm // 限流算法，默认 GCRA
+	Limit     int                // Period 内允许的请求数
+	Period    time.Duration      // 统计周期，默认 1 秒
+	Burst     int                // 令牌桶容量/GCRA 突发量，默认等于 Limit；滑动窗口日志不使用
+	KeyFunc   RateLimitKeyFunc   // 限流维度，默认按客户端 IP
+	Prefix    string             // redis key 前缀，默认 gb:ratelimit
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimiter 限流器。
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (*RateLimitResult, error)
+}
+
+// RateLimitKeyByIP 按客户端 IP 限流。
+func RateLimitKeyByIP() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// RateLimitKeyByIdentity 按 JWT 身份限流，未认证的请求退化为按 IP 限流。
+func RateLimitKeyByIdentity(identityKey ...string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if identity := GetIdentity(c, identityKey...); identity != "" {
+			return "id:" + identity
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// RateLimitKeyByRoute 按路由模板限流，所有调用方共享同一配额。
+func RateLimitKeyByRoute() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		return "route:" + c.Request.Method + ":" + c.FullPath()
+	}
+}
+
+// MiddlewareRateLimit 限流中间件，InsRedis 可用时使用 redis 实现分布式限流，否则退化为进程内限流。
+func MiddlewareRateLimit(config RateLimitConfig) gin.HandlerFunc {
+	config = config.withDefaults()
+	redisLimiter := NewRedisRateLimiter(config)
+	memoryLimiter := NewMemoryRateLimiter(config)
+
+	return func(c *gin.Context) {
+		key := config.KeyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		var (
+			result *RateLimitResult
+			err    error
+		)
+		if InsRedis != nil && InsRedis.UniversalClient != nil {
+			result, err = redisLimiter.Allow(c.Request.Context(), key)
+		}
+		if result == nil {
+			if err != nil {
+				WriteGinWarnLog(c, "redis rate limit err, fallback to memory: %s", err.Error())
+			}
+			result, _ = memoryLimiter.Allow(c.Request.Context(), key)
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			ResponseError(c, ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// withDefaults 方法用于处理withDefaults相关逻辑。
+func (config RateLimitConfig) withDefaults() RateLimitConfig {
+	if config.Algorithm == "" {
+		config.Algorithm = RateLimitGCRA
+	}
+	if config.Limit <= 0 {
+		config.Limit = 1
+	}
+	if config.Period <= 0 {
+		config.Period = time.Second
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = RateLimitKeyByIP()
+	}
+	if config.Prefix == "" {
+		config.Prefix = "gb:ratelimit"
+	}
+	return config
+}
+
+// capacity 令牌桶/GCRA 使用 Burst 作为容量，滑动窗口日志使用 Limit。
+func (config RateLimitConfig) capacity() int {
+	if config.Algorithm == RateLimitSlidingWindowLog {
+		return config.Limit
+	}
+	return config.Burst
+}
+
+// emissionInterval 两次请求之间的平均间隔。
+func (config RateLimitConfig) emissionInterval() time.Duration {
+	return config.Period / time.Duration(config.Limit)
+}
+
+type redisRateLimiter struct {
+	config RateLimitConfig
+}
+
+// NewRedisRateLimiter 创建基于 InsRedis 的限流器。
+func NewRedisRateLimiter(config RateLimitConfig) RateLimiter {
+	return &redisRateLimiter{config: config.withDefaults()}
+}
+
+var (
+	// 参数: 间隔(微秒) 容差(微秒)；返回: 是否允许 剩余次数 重试等待(微秒)
+	rateLimitGCRAScript = redis.NewScript(`local t = redis.call('TIME')
+			local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+			local interval = tonumber(ARGV[1])
+			local tolerance = tonumber(ARGV[2])
+			local tat = tonumber(redis.call('GET', KEYS[1]))
+			if not tat or tat < now then
+				tat = now
+			end
+			local new_tat = tat + interval
+			local allow_at = new_tat - tolerance
+			if now < allow_at then
+				return {0, 0, allow_at - now}
+			end
+			redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+			return {1, math.floor((now - allow_at) / interval), 0}`)
+
+	// 参数: 容量 每微秒生成的令牌数；返回: 是否允许 剩余令牌 重试等待(微秒)
+	rateLimitTokenBucketScript = redis.NewScript(`local t = redis.call('TIME')
+			local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+			local capacity = tonumber(ARGV[1])
+			local rate = tonumber(ARGV[2])
+			local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+			local tokens = tonumber(data[1]) or capacity
+			local ts = tonumber(data[2]) or now
+			tokens = math.min(capacity, tokens + (now - ts) * rate)
+			local allowed = 0
+			local retry = 0
+			if tokens >= 1 then
+				tokens = tokens - 1
+				allowed = 1
+			else
+				retry = math.ceil((1 - tokens) / rate)
+			end
+			redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+			redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate / 1000) + 1000)
+			return {allowed, math.floor(tokens), retry}`)
+
+	// 参数: 窗口(微秒) 上限 成员；返回: 是否允许 剩余次数 重试等待(微秒)
+	rateLimitSlidingWindowLogScript = redis.NewScript(`local t = redis.call('TIME')
+			local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+			local window = tonumber(ARGV[1])
+			local limit = tonumber(ARGV[2])
+			redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+			local count = redis.call('ZCARD', KEYS[1])
+			if count < limit then
+				redis.call('ZADD', KEYS[1], now, ARGV[3])
+				redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+				return {1, limit - count - 1, 0}
+			end
+			local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+			return {0, 0, tonumber(oldest[2]) + window - now}`)
+)
+
+// Allow 方法用于处理Allow相关逻辑。
+func (l *redisRateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return nil, redisClientNilErr()
+	}
+	config := l.config
+	redisKey := fmt.Sprintf("%s:%s:%s", config.Prefix, config.Algorithm, key)
+
+	var (
+		values []int64
+		err    error
+	)
+	switch config.Algorithm {
+	case RateLimitTokenBucket:
+		rate := float64(config.Limit) / float64(config.Period.Microseconds())
+		values, err = rateLimitTokenBucketScript.Run(ctx, InsRedis, []string{redisKey}, config.Burst, rate).Int64Slice()
+	case RateLimitSlidingWindowLog:
+		values, err = rateLimitSlidingWindowLogScript.Run(ctx, InsRedis, []string{redisKey}, config.Period.Microseconds(), config.Limit, uuid.NewString()).Int64Slice()
+	default:
+		interval := config.emissionInterval().Microseconds()
+		values, err = rateLimitGCRAScript.Run(ctx, InsRedis, []string{redisKey}, interval, interval*int64(config.Burst)).Int64Slice()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      config.capacity(),
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+	}, nil
+}
+
+type memoryRateLimitState struct {
+	tat       time.Time   // GCRA 理论到达时间
+	tokens    float64     // 令牌桶剩余令牌
+	last      time.Time   // 令牌桶上次补充时间
+	log       []time.Time // 滑动窗口日志
+	expiresAt time.Time
+}
+
+type memoryRateLimiter struct {
+	config    RateLimitConfig
+	mu        sync.Mutex
+	states    map[string]*memoryRateLimitState
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimiter 创建进程内限流器，多实例部署时各实例独立计数。
+func NewMemoryRateLimiter(config RateLimitConfig) RateLimiter {
+	return &memoryRateLimiter{
+		config: config.withDefaults(),
+		states: make(map[string]*memoryRateLimitState),
+	}
+}
+
+// Allow 方法用于处理Allow相关逻辑。
+func (l *memoryRateLimiter) Allow(_ context.Context, key string) (*RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	state, ok := l.states[key]
+	if !ok {
+		state = &memoryRateLimitState{}
+		l.states[key] = state
+	}
+
+	config := l.config
+	result := &RateLimitResult{Limit: config.capacity()}
+	switch config.Algorithm {
+	case RateLimitTokenBucket:
+		rate := float64(config.Limit) / float64(config.Period)
+		if state.last.IsZero() {
+			state.tokens = float64(config.Burst)
+		} else {
+			state.tokens = math.Min(float64(config.Burst), state.tokens+float64(now.Sub(state.last))*rate)
+		}
+		state.last = now
+		if state.tokens >= 1 {
+			state.tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration(math.Ceil((1 - state.tokens) / rate))
+		}
+		result.Remaining = int(state.tokens)
+		state.expiresAt = now.Add(time.Duration(float64(config.Burst) / rate))
+	case RateLimitSlidingWindowLog:
+		windowStart := now.Add(-config.Period)
+		kept := state.log[:0]
+		for _, t := range state.log {
+			if t.After(windowStart) {
+				kept = append(kept, t)
+			}
+		}
+		state.log = kept
+		if len(state.log) < config.Limit {
+			state.log = append(state.log, now)
+			result.Allowed = true
+			result.Remaining = config.Limit - len(state.log)
+		} else {
+			result.RetryAfter = state.log[0].Add(config.Period).Sub(now)
+		}
+		state.expiresAt = now.Add(config.Period)
+	default:
+		interval := config.emissionInterval()
+		tat := state.tat
+		if tat.Before(now) {
+			tat = now
+		}
+		newTat := tat.Add(interval)
+		allowAt := newTat.Add(-interval * time.Duration(config.Burst))
+		if now.Before(allowAt) {
+			result.RetryAfter = allowAt.Sub(now)
+		} else {
+			state.tat = newTat
+			result.Allowed = true
+			result.Remaining = int(now.Sub(allowAt) / interval)
+		}
+		state.expiresAt = state.tat
+	}
+	return result, nil
+}
+
+// sweep 定期清理过期的限流状态，避免 key 无限增长。
+func (l *memoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.config.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, state := range l.states {
+		if now.After(state.expiresAt) {
+			delete(l.states, key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package gb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitTestEngine(config RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/limited", MiddlewareRateLimit(config), func(c *gin.Context) {
+		ResponseSuccess(c, "ok")
+	})
+	return engine
+}
+
+func getRateLimited(engine *gin.Engine) (*httptest.ResponseRecorder, Response) {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// assertRateLimit 连续请求 limit 次应全部放行且剩余次数递减，下一次返回 429 并带上 Retry-After。
+func assertRateLimit(t *testing.T, engine *gin.Engine, limit int, retryAfter string) {
+	t.Helper()
+	for i := 1; i <= limit; i++ {
+		w, resp := getRateLimited(engine)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("request %d = %+v, want allowed", i, resp)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != strconv.Itoa(limit) {
+			t.Fatalf("request %d X-RateLimit-Limit = %q", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(limit-i) {
+			t.Fatalf("request %d X-RateLimit-Remaining = %q, want %d", i, got, limit-i)
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Fatalf("request %d Retry-After = %q on allowed request", i, got)
+		}
+	}
+
+	w, resp := getRateLimited(engine)
+	if resp.Code != ErrTooManyRequests.Code {
+		t.Fatalf("request %d = %+v, want ErrTooManyRequests", limit+1, resp)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Fatalf("rejected X-RateLimit-Remaining = %q", got)
+	}
+	if got := w.Header().Get("Retry-After"); got != retryAfter {
+		t.Fatalf("rejected Retry-After = %q, want %s", got, retryAfter)
+	}
+}
+
+func TestRateLimitAlgorithms(t *testing.T) {
+	cases := []struct {
+		algorithm  RateLimitAlgorithm
+		retryAfter string
+	}{
+		// 每分钟 3 次：GCRA/令牌桶 20 秒后恢复一次，滑动窗口要等最早的请求滑出窗口
+		{RateLimitGCRA, "20"},
+		{RateLimitTokenBucket, "20"},
+		{RateLimitSlidingWindowLog, "60"},
+	}
+	backends := map[string]func(*testing.T){
+		"redis":  func(t *testing.T) { setupTestRedis(t) },
+		"memory": func(*testing.T) {},
+	}
+	for _, tc := range cases {
+		for name, setup := range backends {
+			t.Run(string(tc.algorithm)+"/"+name, func(t *testing.T) {
+				setup(t)
+				engine := newRateLimitTestEngine(RateLimitConfig{Algorithm: tc.algorithm, Limit: 3, Period: time.Minute})
+				assertRateLimit(t, engine, 3, tc.retryAfter)
+			})
+		}
+	}
+}
+
+func TestRateLimitRedisStoresState(t *testing.T) {
+	m := setupTestRedis(t)
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 3, Period: time.Minute})
+	getRateLimited(engine)
+	if !m.Exists("gb:ratelimit:gcra:ip:192.0.2.1") {
+		t.Fatalf("redis keys = %v, want GCRA state in redis", m.Keys())
+	}
+}
+
+func TestRateLimitFallsBackToMemoryOnRedisError(t *testing.T) {
+	m := setupTestRedis(t)
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 2, Period: time.Minute})
+	m.Close()
+	assertRateLimit(t, engine, 2, "30")
+}
+
+func TestRateLimitSkipsEmptyKey(t *testing.T) {
+	engine := newRateLimitTestEngine(RateLimitConfig{Limit: 1, Period: time.Minute, KeyFunc: func(*gin.Context) string { return "" }})
+	for i := 0; i < 3; i++ {
+		w, resp := getRateLimited(engine)
+		if resp.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("request %d = %+v, headers %v; want unlimited", i, resp, w.Header())
+		}
+	}
+}
@@ -1,29 +1,229 @@
 package gb
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"regexp"
 	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+type RecoveryConfig struct {
+	Logger      GBLog           // 日志，默认 GbDefaultlogger
+	HideDetails bool            // 是否对客户端隐藏 panic 详情；release 模式下始终隐藏
+	HTTPStatus  int             // panic 时的 HTTP 状态码，默认 200（与 ResponseError 一致）
+	Reporters   []PanicReporter // panic 上报
+	DedupWindow time.Duration   // 相同堆栈在该时间内只上报一次，默认 1 分钟
+}
+
+// PanicReport 上报给 PanicReporter 的 panic 信息。
+type PanicReport struct {
+	Time       time.Time `json:"time"`
+	Value      string    `json:"value"`
+	Stack      string    `json:"stack"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route,omitempty"`
+	ClientIP   string    `json:"client_ip"`
+	Identity   string    `json:"identity,omitempty"`
+	Suppressed int       `json:"suppressed"` // 上次上报后被去重的次数
+}
+
+// PanicReporter panic 上报钩子，在独立协程中调用。
+type PanicReporter func(report PanicReport)
+
 // MiddlewareRecovery 函数用于处理MiddlewareRecovery相关逻辑。
 func MiddlewareRecovery(log ...GBLog) gin.HandlerFunc {
-	var loclLog GBLog
-
+	var config RecoveryConfig
 	if len(log) > 0 {
-		loclLog = log[0]
-	} else {
-		loclLog = new(GbDefaultlogger)
+		config.Logger = log[0]
+	}
+	return MiddlewareRecoveryWithConfig(config)
+}
+
+// MiddlewareRecoveryWithConfig 捕获 panic：记录堆栈、按配置上报并返回 ErrServerBusy。
+// 客户端断开（broken pipe / connection reset）引起的 panic 只记录日志，不视为服务端错误。
+func MiddlewareRecoveryWithConfig(config RecoveryConfig) gin.HandlerFunc {
+	if config.Logger == nil {
+		config.Logger = new(GbDefaultlogger)
+	}
+	if config.HTTPStatus == 0 {
+		config.HTTPStatus = http.StatusOK
+	}
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = time.Minute
 	}
+	dedup := newPanicDeduper(config.DedupWindow)
+
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				loclLog.Errorf("panic:%s;stack:%s", err, string(debug.Stack()))
-				ResponseError(c, ErrServerBusy.WithMessage("panic:%v", err))
-				c.AbortWithStatus(http.StatusOK)
+			value := recover()
+			if value == nil {
+				return
+			}
+			// http.ErrAbortHandler 表示有意中断响应，需继续向上 panic，由 net/http 断开连接而不是返回不完整的 200
+			if err, ok := value.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(value)
+			}
+
+			if isBrokenPipe(value) {
+				config.Logger.Infof("client disconnected: %v; %s %s", value, c.Request.Method, c.Request.URL.Path)
+				if err, ok := value.(error); ok {
+					c.Error(err)
+				}
+				c.Abort()
+				return
 			}
+
+			stack := string(debug.Stack())
+			config.Logger.Errorf("panic:%v;trace_id:%s;stack:%s", value, c.GetString("trace_id"), stack)
+
+			if len(config.Reporters) > 0 {
+				if suppressed, report := dedup.check(stack); report {
+					panicReport := PanicReport{
+						Time:       Now(),
+						Value:      fmt.Sprint(value),
+						Stack:      stack,
+						TraceID:    c.GetString("trace_id"),
+						Method:     c.Request.Method,
+						Path:       c.Request.URL.Path,
+						Route:      c.FullPath(),
+						ClientIP:   c.ClientIP(),
+						Identity:   GetIdentity(c),
+						Suppressed: suppressed,
+					}
+					for _, reporter := range config.Reporters {
+						go reporter(panicReport)
+					}
+				}
+			}
+
+			appErr := ErrServerBusy
+			if !config.HideDetails && gin.Mode() != gin.ReleaseMode {
+				appErr = ErrServerBusy.WithMessage("panic:%v", value)
+			}
+			c.Set("resp-status", appErr.Code)
+			c.Set("resp-msg", appErr.Message)
+			setTraceHeaders(c)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(config.HTTPStatus, &Response{
+				Code:    appErr.Code,
+				Message: appErr.Message,
+			})
 		}()
 		c.Next()
 	}
 }
+
+// isBrokenPipe 判断 panic 是否由客户端断开连接引起。
+func isBrokenPipe(value any) bool {
+	err, ok := value.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		var syscallErr *os.SyscallError
+		if errors.As(opErr, &syscallErr) {
+			msg := strings.ToLower(syscallErr.Error())
+			return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+		}
+	}
+	return false
+}
+
+// panicDeduper 按去除地址与协程编号后的堆栈去重。
+type panicDeduper struct {
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[string]*panicSeen
+}
+
+type panicSeen struct {
+	reportedAt time.Time
+	suppressed int
+}
+
+var stackNoise = regexp.MustCompile(`goroutine \d+|\+0x[0-9a-f]+|0x[0-9a-f]+`)
+
+// newPanicDeduper 函数用于处理newPanicDeduper相关逻辑。
+func newPanicDeduper(window time.Duration) *panicDeduper {
+	return &panicDeduper{window: window, seen: make(map[string]*panicSeen)}
+}
+
+// check 返回是否需要上报，以及上次上报后被去重的次数。
+func (d *panicDeduper) check(stack string) (int, bool) {
+	sum := sha256.Sum256([]byte(stackNoise.ReplaceAllString(stack, "")))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, s := range d.seen {
+		if now.Sub(s.reportedAt) > d.window*10 {
+			delete(d.seen, k)
+		}
+	}
+	s, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = &panicSeen{reportedAt: now}
+		return 0, true
+	}
+	if now.Sub(s.reportedAt) < d.window {
+		s.suppressed++
+		return 0, false
+	}
+	suppressed := s.suppressed
+	s.reportedAt, s.suppressed = now, 0
+	return suppressed, true
+}
+
+// NewWebhookPanicReporter 以 JSON POST 到 webhook 地址。
+func NewWebhookPanicReporter(url string, headers ...map[string]string) PanicReporter {
+	return func(report PanicReport) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req := R().SetContext(ctx).SetBody(report)
+		if len(headers) > 0 {
+			req.SetHeaders(headers[0])
+		}
+		if _, err := req.Post(url); err != nil {
+			zlog.Warn().Err(err).Msg("panic webhook report failed")
+		}
+	}
+}
+
+// NewFilePanicReporter 以 JSON 行追加写入文件。
+func NewFilePanicReporter(path string) PanicReporter {
+	var mu sync.Mutex
+	return func(report PanicReport) {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			zlog.Warn().Err(err).Msg("panic file report failed")
+			return
+		}
+		defer file.Close()
+		file.Write(append(data, '\n'))
+	}
+}
@@ -0,0 +1,65 @@
+package gb
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRecoveryTestEngine(config RecoveryConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareRecoveryWithConfig(config))
+	engine.GET("/panic", func(c *gin.Context) { panic("secret db password") })
+	engine.GET("/broken-pipe", func(c *gin.Context) {
+		panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	engine.GET("/abort", func(c *gin.Context) { panic(http.ErrAbortHandler) })
+	return engine
+}
+
+func TestRecoveryHidesDetails(t *testing.T) {
+	engine := newRecoveryTestEngine(RecoveryConfig{HideDetails: true, HTTPStatus: http.StatusInternalServerError})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != ErrServerBusy.Code || strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestRecoveryBrokenPipe(t *testing.T) {
+	engine := newRecoveryTestEngine(RecoveryConfig{})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/broken-pipe", nil))
+	if w.Body.Len() != 0 {
+		t.Fatalf("broken pipe wrote a response: %s", w.Body.String())
+	}
+}
+
+func TestRecoveryRepanicsErrAbortHandler(t *testing.T) {
+	server := httptest.NewUnstartedServer(newRecoveryTestEngine(RecoveryConfig{}))
+	// net/http 不会记录 ErrAbortHandler，这里同时屏蔽其他错误日志
+	server.Config.ErrorLog = nil
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/abort")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("request completed with status %d, want aborted connection", resp.StatusCode)
+	}
+}
@@ -0,0 +1,140 @@
+package gb
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testSignatureAppKey = "app-1"
+	testSignatureSecret = "secret-1"
+)
+
+func newSignatureTestEngine(config SignatureConfig) *gin.Engine {
+	if config.Secrets == nil && config.SecretFunc == nil {
+		config.Secrets = map[string]string{testSignatureAppKey: testSignatureSecret}
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/open/orders", MiddlewareSignature(config), func(c *gin.Context) {
+		ResponseSuccess(c, c.GetString(SignatureAppKeyContextKey))
+	})
+	return engine
+}
+
+func newSignedRequest(t *testing.T, target, body string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if err := SignRequest(req, testSignatureAppKey, testSignatureSecret); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func cloneSignedRequest(req *http.Request, body string) *http.Request {
+	replay := httptest.NewRequest(req.Method, req.URL.String(), strings.NewReader(body))
+	replay.Header = req.Header.Clone()
+	return replay
+}
+
+func serveSigned(engine *gin.Engine, req *http.Request) Response {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	engine := newSignatureTestEngine(SignatureConfig{})
+	resp := serveSigned(engine, newSignedRequest(t, "/open/orders?b=2&a=1", `{"sku":1}`))
+	if resp.Code != http.StatusOK || resp.Data != testSignatureAppKey {
+		t.Fatalf("signed request = %+v", resp)
+	}
+}
+
+func TestSignatureRejectsTamperedRequest(t *testing.T) {
+	engine := newSignatureTestEngine(SignatureConfig{})
+
+	req := newSignedRequest(t, "/open/orders", `{"amount":1}`)
+	req.Body = http.NoBody
+	if resp := serveSigned(engine, req); resp.Code != ErrSignatureInvalid.Code {
+		t.Fatalf("tampered body = %+v, want ErrSignatureInvalid", resp)
+	}
+
+	req = newSignedRequest(t, "/open/orders?amount=1", "")
+	req.URL.RawQuery = "amount=100"
+	if resp := serveSigned(engine, req); resp.Code != ErrSignatureInvalid.Code {
+		t.Fatalf("tampered query = %+v, want ErrSignatureInvalid", resp)
+	}
+}
+
+func TestSignatureRejectsReplay(t *testing.T) {
+	for name, setup := range map[string]func(*testing.T){
+		"memory": func(*testing.T) {},
+		"redis":  func(t *testing.T) { setupTestRedis(t) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			setup(t)
+			engine := newSignatureTestEngine(SignatureConfig{})
+			req := newSignedRequest(t, "/open/orders", `{"sku":1}`)
+			replay := cloneSignedRequest(req, `{"sku":1}`)
+			if resp := serveSigned(engine, req); resp.Code != http.StatusOK {
+				t.Fatalf("first request = %+v", resp)
+			}
+			if resp := serveSigned(engine, replay); resp.Code != ErrSignatureReplayed.Code {
+				t.Fatalf("replayed request = %+v, want ErrSignatureReplayed", resp)
+			}
+		})
+	}
+}
+
+func TestSignatureExpired(t *testing.T) {
+	engine := newSignatureTestEngine(SignatureConfig{Now: func() time.Time { return time.Now().Add(10 * time.Minute) }})
+	if resp := serveSigned(engine, newSignedRequest(t, "/open/orders", "")); resp.Code != ErrSignatureExpired.Code {
+		t.Fatalf("stale request = %+v, want ErrSignatureExpired", resp)
+	}
+}
+
+func TestSignatureNonceUsesConfigClock(t *testing.T) {
+	now := time.Now()
+	engine := newSignatureTestEngine(SignatureConfig{NonceTTL: time.Minute, Now: func() time.Time { return now }})
+	req := newSignedRequest(t, "/open/orders", "")
+	replay := cloneSignedRequest(req, "")
+	if resp := serveSigned(engine, req); resp.Code != http.StatusOK {
+		t.Fatalf("first request = %+v", resp)
+	}
+	// 按配置的时钟 nonce 已过期，仍在时间窗口内
+	now = now.Add(2 * time.Minute)
+	if resp := serveSigned(engine, replay); resp.Code != http.StatusOK {
+		t.Fatalf("nonce expiry ignored config.Now: %+v", resp)
+	}
+}
+
+func TestSignatureLimitsBody(t *testing.T) {
+	engine := newSignatureTestEngine(SignatureConfig{MaxBody: 16})
+	if resp := serveSigned(engine, newSignedRequest(t, "/open/orders", strings.Repeat("x", 64))); resp.Code != ErrRequestBodyTooLarge.Code {
+		t.Fatalf("oversized body = %+v, want ErrRequestBodyTooLarge", resp)
+	}
+}
+
+func TestSignatureSecretLookupError(t *testing.T) {
+	engine := newSignatureTestEngine(SignatureConfig{SecretFunc: func(string) (string, error) {
+		return "", errors.New("db down")
+	}})
+	if resp := serveSigned(engine, newSignedRequest(t, "/open/orders", "")); resp.Code != ErrServerBusy.Code {
+		t.Fatalf("lookup failure = %+v, want ErrServerBusy", resp)
+	}
+
+	engine = newSignatureTestEngine(SignatureConfig{SecretFunc: func(string) (string, error) { return "", nil }})
+	if resp := serveSigned(engine, newSignedRequest(t, "/open/orders", "")); resp.Code != ErrSignatureInvalid.Code {
+		t.Fatalf("unknown app-key = %+v, want ErrSignatureInvalid", resp)
+	}
+}
@@ -0,0 +1,81 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	timeoutBaseContextKey   = "timeout_base_ctx"
+	timeoutActiveContextKey = "timeout_active_ctx"
+)
+
+type TimeoutConfig struct {
+	Default time.Duration            // 默认超时时间，小于等于 0 表示不限制
+	Routes  map[string]time.Duration // 按路由覆盖超时时间，key 为 "GET /api/v1/export" 或 "/api/v1/export"
+}
+
+// MiddlewareTimeout 为请求 context 设置截止时间，超时后返回 ErrRequestTimeout。
+// 处理函数需通过 GinContext / InsDB.WithGinContext 使用该 context，才能在超时后真正取消数据库与 redis 操作。
+func MiddlewareTimeout(config TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(timeoutBaseContextKey, c.Request.Context())
+
+		timeout := config.Default
+		if d, ok := config.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = d
+		} else if d, ok := config.Routes[c.FullPath()]; ok {
+			timeout = d
+		}
+		runWithTimeout(c, c.Request.Context(), timeout)
+	}
+}
+
+// GinRouteTimeout 路由级超时设置，会覆盖 MiddlewareTimeout 设置的全局超时时间（可以更长也可以更短）。
+func GinRouteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := c.Request.Context()
+		if value, exists := c.Get(timeoutBaseContextKey); exists {
+			if ctx, ok := value.(context.Context); ok {
+				base = ctx
+			}
+		}
+		runWithTimeout(c, base, timeout)
+	}
+}
+
+// runWithTimeout 函数用于处理runWithTimeout相关逻辑。
+func runWithTimeout(c *gin.Context, base context.Context, timeout time.Duration) {
+	if timeout <= 0 {
+		c.Next()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(base, timeout)
+	defer cancel()
+	request := c.Request
+	c.Request = request.WithContext(ctx)
+	c.Set(timeoutActiveContextKey, ctx)
+	c.Next()
+	c.Request = request
+
+	// 内层路由级超时覆盖了本层设置时，由内层负责判断
+	if active, _ := c.Get(timeoutActiveContextKey); active != ctx {
+		return
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+		ResponseError(c, ErrRequestTimeout)
+		c.Abort()
+	}
+}
+
+// GinContext 返回请求的 context，传给 redis 等调用以便在请求超时或客户端断开时取消。
+func GinContext(c *gin.Context) context.Context {
+	if c == nil || c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
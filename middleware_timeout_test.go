@@ -0,0 +1,140 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func serveTimeout(engine *gin.Engine, target string) (*httptest.ResponseRecorder, Response) {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// waitRequest 模拟遵循 context 的慢处理函数，最多等待 max。
+func waitRequest(c *gin.Context, max time.Duration) {
+	select {
+	case <-GinContext(c).Done():
+	case <-time.After(max):
+	}
+}
+
+func newTimeoutTestEngine(config TimeoutConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareTimeout(config))
+	return engine
+}
+
+func TestTimeoutSlowHandler(t *testing.T) {
+	engine := newTimeoutTestEngine(TimeoutConfig{Default: 20 * time.Millisecond})
+	engine.GET("/slow", func(c *gin.Context) {
+		waitRequest(c, time.Second)
+	})
+	engine.GET("/fast", func(c *gin.Context) {
+		ResponseSuccess(c, "ok")
+	})
+
+	if _, resp := serveTimeout(engine, "/slow"); resp.Code != ErrRequestTimeout.Code {
+		t.Fatalf("slow handler = %+v, want ErrRequestTimeout", resp)
+	}
+	if _, resp := serveTimeout(engine, "/fast"); resp.Code != http.StatusOK {
+		t.Fatalf("fast handler = %+v", resp)
+	}
+}
+
+func TestTimeoutKeepsWrittenResponse(t *testing.T) {
+	engine := newTimeoutTestEngine(TimeoutConfig{Default: 20 * time.Millisecond})
+	engine.GET("/written", func(c *gin.Context) {
+		ResponseSuccess(c, "partial")
+		waitRequest(c, time.Second)
+	})
+
+	w, resp := serveTimeout(engine, "/written")
+	if resp.Code != http.StatusOK || resp.Data != "partial" {
+		t.Fatalf("written response = %s, want the handler's own body only", w.Body.String())
+	}
+}
+
+func TestTimeoutRouteOverrides(t *testing.T) {
+	engine := newTimeoutTestEngine(TimeoutConfig{
+		Default: 20 * time.Millisecond,
+		Routes:  map[string]time.Duration{"GET /config-longer": time.Second},
+	})
+	sleepThenRespond := func(c *gin.Context) {
+		waitRequest(c, 60*time.Millisecond)
+		if GinContext(c).Err() == nil {
+			ResponseSuccess(c, "ok")
+		}
+	}
+	engine.GET("/longer", GinRouteTimeout(time.Second), sleepThenRespond)
+	engine.GET("/config-longer", sleepThenRespond)
+
+	for _, target := range []string{"/longer", "/config-longer"} {
+		if _, resp := serveTimeout(engine, target); resp.Code != http.StatusOK {
+			t.Fatalf("%s = %+v, want the longer route timeout to apply", target, resp)
+		}
+	}
+
+	engine = newTimeoutTestEngine(TimeoutConfig{Default: time.Second})
+	var waited time.Duration
+	engine.GET("/shorter", GinRouteTimeout(10*time.Millisecond), func(c *gin.Context) {
+		start := time.Now()
+		waitRequest(c, time.Second)
+		waited = time.Since(start)
+	})
+	if _, resp := serveTimeout(engine, "/shorter"); resp.Code != ErrRequestTimeout.Code {
+		t.Fatalf("shorter route timeout = %+v, want ErrRequestTimeout", resp)
+	}
+	if waited >= 500*time.Millisecond {
+		t.Fatalf("shorter route waited %s, want the route timeout to cancel the context", waited)
+	}
+}
+
+func TestTimeoutCancelsGormContext(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &GormClient{DB: db}
+
+	engine := newTimeoutTestEngine(TimeoutConfig{Default: 20 * time.Millisecond})
+	var (
+		ctxErr   error
+		deadline time.Time
+		start    = time.Now()
+	)
+	engine.GET("/query", func(c *gin.Context) {
+		ctx := client.WithGinContext(c).Statement.Context
+		deadline, _ = ctx.Deadline()
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+		case <-time.After(time.Second):
+		}
+	})
+
+	if _, resp := serveTimeout(engine, "/query"); resp.Code != ErrRequestTimeout.Code {
+		t.Fatalf("query = %+v, want ErrRequestTimeout", resp)
+	}
+	if !errors.Is(ctxErr, context.DeadlineExceeded) {
+		t.Fatalf("gorm context err = %v, want deadline exceeded", ctxErr)
+	}
+	if deadline.IsZero() || deadline.Sub(start) > 100*time.Millisecond {
+		t.Fatalf("gorm context deadline = %v, want about 20ms after %v", deadline, start)
+	}
+}
@@ -1,28 +1,87 @@
 package gb
 
 import (
+	"context"
+	"crypto/rand"
+	"strings"
+
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const TraceIDHeader = "Trace-Id"
 
-// MiddlewareTraceID 函数用于处理MiddlewareTraceID相关逻辑。
+// MiddlewareTraceID 解析 W3C traceparent/tracestate 并为请求创建 server span，Trace-Id 与 X-Request-Id 使用 W3C trace id。
+// 请求未携带 traceparent 时，兼容使用 32 位十六进制的 Trace-Id / X-Request-Id 作为 trace id，否则随机生成。
+// 未调用 InitTracing 时不导出 span，但 trace id 仍会传播到 GORM、redis 与 R() 发出的请求。
 func MiddlewareTraceID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traceID := c.Request.Header.Get(TraceIDHeader)
-		if traceID == "" {
-			traceID = c.Request.Header.Get("trace_id")
+		ctx := traceContextPropagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			if traceID := legacyTraceID(c); traceID.IsValid() {
+				// 旧版请求头只用于关联 trace id，不携带上游的采样决定，由本服务的采样率决定是否采样
+				ctx = context.WithValue(ctx, legacyTraceContextKey{}, true)
+				ctx = trace.ContextWithRemoteSpanContext(ctx, newRemoteSpanContext(traceID, 0))
+			}
 		}
-		if traceID == "" {
-			traceID = c.Request.Header.Get("X-Request-Id")
+
+		opts := []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			),
 		}
-		if traceID == "" {
-			traceID = uuid.NewString()
+		name := c.Request.Method + " " + c.Request.URL.Path
+		spanCtx, span := tracer().Start(ctx, name, opts...)
+		if !span.SpanContext().IsValid() {
+			// 未初始化 tracing 时 noop tracer 不生成 id，使用随机的远端父节点以保证 trace id 存在
+			ctx = trace.ContextWithRemoteSpanContext(ctx, newRemoteSpanContext(trace.TraceID{}, trace.FlagsSampled))
+			spanCtx, span = tracer().Start(ctx, name, opts...)
 		}
+		ctx = spanCtx
+		defer finishGinSpan(c, span)
+		c.Request = c.Request.WithContext(ctx)
+
+		traceID := span.SpanContext().TraceID().String()
 		c.Header(TraceIDHeader, traceID)
 		c.Header("X-Request-Id", traceID)
+		traceContextPropagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
 		c.Set("trace_id", traceID)
+		c.Set("span_id", span.SpanContext().SpanID().String())
 		c.Next()
 	}
 }
+
+// legacyTraceID 读取旧版 Trace-Id / trace_id / X-Request-Id 请求头，仅接受合法的 W3C trace id。
+func legacyTraceID(c *gin.Context) trace.TraceID {
+	for _, header := range []string{TraceIDHeader, "trace_id", "X-Request-Id"} {
+		value := strings.ReplaceAll(strings.ToLower(c.GetHeader(header)), "-", "")
+		if traceID, err := trace.TraceIDFromHex(value); err == nil {
+			return traceID
+		}
+	}
+	return trace.TraceID{}
+}
+
+// legacyTraceContextKey 标记远端父节点来自旧版 Trace-Id 请求头，见 legacyTraceSampler。
+type legacyTraceContextKey struct{}
+
+// newRemoteSpanContext 生成作为远端父节点的 span context，traceID 为空时随机生成。
+func newRemoteSpanContext(traceID trace.TraceID, flags trace.TraceFlags) trace.SpanContext {
+	if !traceID.IsValid() {
+		rand.Read(traceID[:])
+	}
+	var spanID trace.SpanID
+	rand.Read(spanID[:])
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+}
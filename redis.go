@@ -280,6 +280,7 @@ func InitRedis(ops ...WithRedisOption) error {
 		panic("redis address is empty")
 	}
 	InsRedis.UniversalClient = redis.NewUniversalClient(opts)
+	InsRedis.UniversalClient.AddHook(redisTracingHook{})
 	return InsRedis.UniversalClient.Ping(context.Background()).Err()
 }
 
@@ -0,0 +1,21 @@
+package gb
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// setupTestRedis 启动 miniredis 并初始化 InsRedis，测试结束后关闭。
+func setupTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	m := miniredis.RunT(t)
+	if err := InitRedis(WithRedisAddressOption([]string{m.Addr()})); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		InsRedis.Close()
+		InsRedis = nil
+	})
+	return m
+}
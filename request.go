@@ -22,19 +22,20 @@ func ReqPageSize(page, size int) (int, int) {
 	return page, (page - 1) * size
 }
 
-// ReqFileUploadGoroutine 函数用于处理ReqFileUploadGoroutine相关逻辑。
+// ReqFileUploadGoroutine 并发执行 uploadFileFunc，并发数受上传协程池限制，返回的地址与错误顺序不固定。
 func ReqFileUploadGoroutine(files []*multipart.FileHeader, uploadFileFunc func(file *multipart.FileHeader) (string, error)) (fileURLS []string, errs []error) {
 	var (
 		group sync.WaitGroup
 		mu    sync.Mutex
 	)
 
+	pool := defaultUploadPool()
 	for _, fileHeader := range files {
 		fh := fileHeader
 		group.Add(1)
-		go func(header *multipart.FileHeader) {
+		err := pool.Submit(func() {
 			defer group.Done()
-			url, err := uploadFileFunc(header)
+			url, err := uploadFileFunc(fh)
 			mu.Lock()
 			defer mu.Unlock()
 			if err != nil {
@@ -42,7 +43,13 @@ func ReqFileUploadGoroutine(files []*multipart.FileHeader, uploadFileFunc func(f
 				return
 			}
 			fileURLS = append(fileURLS, url)
-		}(fh)
+		})
+		if err != nil {
+			group.Done()
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}
 	}
 	group.Wait()
 
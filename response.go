@@ -1,6 +1,7 @@
 package gb
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -56,25 +57,49 @@ var (
 	ErrTokenInvalid = NewAppError(400002, "token验证失败")
 
 	// 401xxx 未授权
-	ErrUnauthorized = NewAppError(401000, "用户未登录或token已失效")
+	ErrUnauthorized      = NewAppError(401000, "用户未登录或token已失效")
+	ErrSignatureInvalid  = NewAppError(401001, "签名验证失败")
+	ErrSignatureExpired  = NewAppError(401002, "请求时间戳已过期")
+	ErrSignatureReplayed = NewAppError(401003, "请求已被处理,请勿重放")
 
 	// 403xxx 禁止操作
 	ErrForbiddenAuth = NewAppError(403000, "权限不足")
 	ErrUserDisabled  = NewAppError(403001, "用户不存在或已被禁用")
+	ErrIPForbidden   = NewAppError(403002, "当前IP不允许访问")
 
 	// 404xxx 数据不存在
 	ErrNotFound = NewAppError(404000, "数据不存在")
 
 	// 409xxx 数据已存在
-	ErrDataExists          = NewAppError(409000, "数据已存在")
-	ErrUniqueIndexConflict = NewAppError(409001, "索引冲突")
+	ErrDataExists            = NewAppError(409000, "数据已存在")
+	ErrUniqueIndexConflict   = NewAppError(409001, "索引冲突")
+	ErrIdempotencyConflict   = NewAppError(409002, "幂等键已被不同的请求内容使用")
+	ErrIdempotencyProcessing = NewAppError(409003, "请求正在处理中,请勿重复提交")
+	ErrUploadOffsetMismatch  = NewAppError(409004, "上传偏移量不一致")
+
+	// 413xxx、415xxx 上传文件与请求体
+	ErrFileTooLarge        = NewAppError(413000, "文件大小超出限制")
+	ErrRequestBodyTooLarge = NewAppError(413001, "请求体大小超出限制")
+	ErrFileTypeNotAllowed  = NewAppError(415000, "不支持的文件类型")
+
+	// 423xxx、460xxx 断点续传
+	ErrUploadLocked           = NewAppError(423000, "文件正在上传中,请稍后再试")
+	ErrUploadChecksumMismatch = NewAppError(460000, "文件校验和不匹配")
+
+	// 429xxx 请求过多
+	ErrTooManyRequests = NewAppError(429000, "请求过于频繁,请稍后再试")
 
 	// 5xxxxx 服务器错误
 	ErrServerBusy = NewAppError(500000, "服务器繁忙")
 	ErrDatabase   = NewAppError(500001, "数据库错误")
 	ErrRedis      = NewAppError(500002, "redis错误")
 
+	ErrMaintenance    = NewAppError(503000, "系统维护中,请稍后再试")
+	ErrReadOnlyMode   = NewAppError(503001, "系统维护中,暂不支持修改操作")
+	ErrRequestTimeout = NewAppError(504000, "请求处理超时")
+
 	EncryptErr = NewAppError(600000, "加密错误")
+	DecryptErr = NewAppError(600001, "解密错误")
 	// ... 可以继续添加其他预定义错误
 )
 
@@ -116,6 +141,8 @@ func ConvertToAppError(err error) *AppError {
 
 	// 映射特定的错误到业务错误
 	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRequestTimeout
 	case errors.Is(err, gorm.ErrRecordNotFound):
 		return ErrNotFound.WithMessage("数据不存在")
 	case errors.Is(err, gorm.ErrDuplicatedKey):
@@ -19,7 +19,10 @@ func initRestyClient() {
 		SetTimeout(30 * time.Second).
 		SetRetryCount(2).
 		SetRetryWaitTime(500 * time.Millisecond).
-		SetRetryMaxWaitTime(2 * time.Second)
+		SetRetryMaxWaitTime(2 * time.Second).
+		OnBeforeRequest(restyTracingBeforeRequest).
+		OnAfterResponse(restyTracingAfterResponse).
+		OnError(restyTracingOnError)
 }
 
 // RestyClient 函数用于处理RestyClient相关逻辑。
@@ -0,0 +1,244 @@
+package gb
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RouteAccessPublic  = "public"  // 经 Router.Public 注册
+	RouteAccessPrivate = "private" // 经 Router.Private 注册，会先经过认证处理器
+	RouteAccessUnknown = "unknown" // 未经 Router 注册，是否需要认证需根据 Middleware 判断
+)
+
+// RouteTableEntry 路由表中的一条路由信息。
+type RouteTableEntry struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Public     bool     `json:"public"`     // 是否经 Router.Public 注册，未经 Router 注册的路由为 false
+	Access     string   `json:"access"`     // RouteAccessPublic、RouteAccessPrivate 或 RouteAccessUnknown
+	Handler    string   `json:"handler"`    // 最终处理函数名
+	Middleware []string `json:"middleware"` // 完整的中间件链（含全局、分组、认证处理器与路由级中间件），不含最终处理函数
+}
+
+// Router 实例级路由注册表，同一进程内可以构建多个（例如对外 API 与管理后台 API）。
+type Router struct {
+	*RouteGroup
+}
+
+// RouteGroup 路由分组，可以嵌套（如 /api/v1、/api/v2），每个分组拥有独立的认证处理器与中间件。
+type RouteGroup struct {
+	prefix     string
+	middleware []gin.HandlerFunc
+	auth       []gin.HandlerFunc
+	hasAuth    bool
+	public     []func(*gin.RouterGroup)
+	private    []func(*gin.RouterGroup)
+	children   []*RouteGroup
+}
+
+// NewRouter 创建以 prefix 为根路径的路由注册表。
+func NewRouter(prefix string, middleware ...gin.HandlerFunc) *Router {
+	return &Router{RouteGroup: newRouteGroup(prefix, middleware...)}
+}
+
+// newRouteGroup 函数用于处理newRouteGroup相关逻辑。
+func newRouteGroup(prefix string, middleware ...gin.HandlerFunc) *RouteGroup {
+	return &RouteGroup{
+		prefix:     prefix,
+		middleware: append([]gin.HandlerFunc(nil), middleware...),
+	}
+}
+
+// Group 创建子分组，子分组继承父分组的中间件，未单独设置认证时继承父分组的认证处理器。
+func (g *RouteGroup) Group(prefix string, middleware ...gin.HandlerFunc) *RouteGroup {
+	child := newRouteGroup(prefix, middleware...)
+	g.children = append(g.children, child)
+	return child
+}
+
+// Version 创建版本分组，如 Version("v1") 对应 /v1。
+func (g *RouteGroup) Version(version string, middleware ...gin.HandlerFunc) *RouteGroup {
+	return g.Group("/"+strings.TrimPrefix(version, "/"), middleware...)
+}
+
+// Use 为分组追加中间件，对公开与私有路由均生效。
+func (g *RouteGroup) Use(middleware ...gin.HandlerFunc) *RouteGroup {
+	g.middleware = append(g.middleware, middleware...)
+	return g
+}
+
+// Auth 设置分组私有路由使用的认证处理器，会覆盖从父分组继承的认证处理器。
+func (g *RouteGroup) Auth(handlers ...gin.HandlerFunc) *RouteGroup {
+	g.auth = append([]gin.HandlerFunc(nil), handlers...)
+	g.hasAuth = true
+	return g
+}
+
+// Public 注册无需认证的路由。
+func (g *RouteGroup) Public(routes ...func(*gin.RouterGroup)) *RouteGroup {
+	g.public = append(g.public, routes...)
+	return g
+}
+
+// Private 注册需要经过认证处理器的路由。
+func (g *RouteGroup) Private(routes ...func(*gin.RouterGroup)) *RouteGroup {
+	g.private = append(g.private, routes...)
+	return g
+}
+
+// Register 将路由注册到 gin 引擎上，并返回本次注册的路由表。
+func (r *Router) Register(engine *gin.Engine) []RouteTableEntry {
+	var table []RouteTableEntry
+	r.RouteGroup.register(engine, &engine.RouterGroup, nil, &table)
+	return table
+}
+
+// register 方法用于处理register相关逻辑。
+func (g *RouteGroup) register(engine *gin.Engine, parent *gin.RouterGroup, inheritedAuth []gin.HandlerFunc, table *[]RouteTableEntry) {
+	group := parent.Group(g.prefix, g.middleware...)
+	auth := inheritedAuth
+	if g.hasAuth {
+		auth = g.auth
+	}
+
+	for _, route := range g.public {
+		recordRoutes(engine, group, true, route, table)
+	}
+
+	if len(g.private) > 0 {
+		priGroup := group.Group("", auth...)
+		for _, route := range g.private {
+			recordRoutes(engine, priGroup, false, route, table)
+		}
+	}
+
+	for _, child := range g.children {
+		child.register(engine, group, auth, table)
+	}
+}
+
+// recordRoutes 执行路由注册函数，并将新增的路由记录到路由表。
+func recordRoutes(engine *gin.Engine, group *gin.RouterGroup, public bool, route func(*gin.RouterGroup), table *[]RouteTableEntry) {
+	*table = append(*table, recordEngineRoute(engine, group, public, func() { route(group) })...)
+}
+
+// recordEngineRoute 执行 register 并返回其新增的路由，group 为注册所在的分组，public 为 false 表示路由需要认证。
+func recordEngineRoute(engine *gin.Engine, group *gin.RouterGroup, public bool, register func()) []RouteTableEntry {
+	before := make(map[string]struct{})
+	for _, info := range engine.Routes() {
+		before[info.Method+" "+info.Path] = struct{}{}
+	}
+
+	register()
+
+	access := RouteAccessPrivate
+	if public {
+		access = RouteAccessPublic
+	}
+	var table []RouteTableEntry
+	chains := routeMiddleware(engine)
+	for _, info := range engine.Routes() {
+		if _, ok := before[info.Method+" "+info.Path]; ok {
+			continue
+		}
+		table = append(table, RouteTableEntry{
+			Method:     info.Method,
+			Path:       info.Path,
+			Public:     public,
+			Access:     access,
+			Handler:    info.Handler,
+			Middleware: routeChain(chains, info.Method+" "+info.Path, group),
+		})
+	}
+	return table
+}
+
+// BuildRouteTable 合并已记录的路由与引擎上的全部路由，未经 Router 注册的路由标记为 RouteAccessUnknown。
+func BuildRouteTable(engine *gin.Engine, recorded []RouteTableEntry) []RouteTableEntry {
+	known := make(map[string]struct{}, len(recorded))
+	table := make([]RouteTableEntry, 0, len(recorded))
+	for _, entry := range recorded {
+		known[entry.Method+" "+entry.Path] = struct{}{}
+		table = append(table, entry)
+	}
+	chains := routeMiddleware(engine)
+	for _, info := range engine.Routes() {
+		if _, ok := known[info.Method+" "+info.Path]; ok {
+			continue
+		}
+		table = append(table, RouteTableEntry{
+			Method:     info.Method,
+			Path:       info.Path,
+			Access:     RouteAccessUnknown,
+			Handler:    info.Handler,
+			Middleware: routeChain(chains, info.Method+" "+info.Path, &engine.RouterGroup),
+		})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		if table[i].Path == table[j].Path {
+			return table[i].Method < table[j].Method
+		}
+		return table[i].Path < table[j].Path
+	})
+	return table
+}
+
+// routeChain 返回路由的中间件链。无法读取路由树时（如 gin 升级后内部字段变化）退回注册分组的中间件链，
+// 此时缺少直接传给路由的中间件，但全局、分组与认证处理器仍然完整。
+func routeChain(chains map[string][]string, key string, group *gin.RouterGroup) []string {
+	if chain, ok := chains[key]; ok {
+		return chain
+	}
+	names := make([]string, 0, len(group.Handlers))
+	for _, handler := range group.Handlers {
+		names = append(names, runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name())
+	}
+	return names
+}
+
+// routeMiddleware 返回每条路由（"METHOD path"）实际注册的中间件链，不含最终处理函数。
+// gin 只通过 Routes() 暴露最终处理函数，这里按 Routes() 相同的方式遍历路由树读取完整的处理链，
+// 从而包含直接传给 GET(path, auth, handler) 的路由级中间件。
+func routeMiddleware(engine *gin.Engine) map[string][]string {
+	chains := make(map[string][]string)
+	trees := reflect.ValueOf(engine).Elem().FieldByName("trees")
+	if trees.Kind() != reflect.Slice {
+		return chains
+	}
+	for i := 0; i < trees.Len(); i++ {
+		method, root := trees.Index(i).FieldByName("method"), trees.Index(i).FieldByName("root")
+		if method.Kind() != reflect.String || root.Kind() != reflect.Pointer {
+			continue
+		}
+		walkRouteNode(method.String(), "", root, chains)
+	}
+	return chains
+}
+
+// walkRouteNode 与 gin 的 iterate 相同，逐层拼接 path 得到与 Routes() 一致的路由路径。
+func walkRouteNode(method, path string, node reflect.Value, chains map[string][]string) {
+	if node.IsNil() {
+		return
+	}
+	n := node.Elem()
+	nodePath, handlers, children := n.FieldByName("path"), n.FieldByName("handlers"), n.FieldByName("children")
+	if nodePath.Kind() != reflect.String || handlers.Kind() != reflect.Slice || children.Kind() != reflect.Slice {
+		return
+	}
+	path += nodePath.String()
+	if handlers.Len() > 0 {
+		names := make([]string, 0, handlers.Len()-1)
+		for i := 0; i < handlers.Len()-1; i++ {
+			names = append(names, runtime.FuncForPC(handlers.Index(i).Pointer()).Name())
+		}
+		chains[method+" "+path] = names
+	}
+	for i := 0; i < children.Len(); i++ {
+		walkRouteNode(method, path, children.Index(i), chains)
+	}
+}
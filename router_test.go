@@ -0,0 +1,173 @@
+package gb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testRouteAuth(c *gin.Context)    { c.Next() }
+func testRouteHandler(c *gin.Context) { ResponseSuccess(c, nil) }
+
+func TestRouteTableRecordsFullChain(t *testing.T) {
+	router := NewRouter("/admin")
+	router.Auth(testRouteAuth)
+	router.Public(func(g *gin.RouterGroup) {
+		g.GET("/login", testRouteHandler)
+		g.GET("/report", testRouteAuth, testRouteHandler)
+	})
+	router.Private(func(g *gin.RouterGroup) {
+		g.GET("/users", testRouteHandler)
+	})
+	server := NewHTTPServer("127.0.0.1:0",
+		WithGinRouterModel(GinModelTest),
+		WithGinSkipLog(true),
+		WithGinRouters(router),
+		WithGinRouterRouteTable("/debug/routes", testRouteAuth),
+	)
+	server.Engine().GET("/raw", testRouteHandler)
+
+	table := make(map[string]RouteTableEntry)
+	for _, entry := range server.Routes() {
+		table[entry.Method+" "+entry.Path] = entry
+	}
+	hasAuth := func(entry RouteTableEntry) bool {
+		for _, name := range entry.Middleware {
+			if strings.HasSuffix(name, ".testRouteAuth") {
+				return true
+			}
+		}
+		return false
+	}
+
+	cases := []struct {
+		key    string
+		access string
+		auth   bool
+	}{
+		{"GET /admin/login", RouteAccessPublic, false},
+		{"GET /admin/report", RouteAccessPublic, true},
+		{"GET /admin/users", RouteAccessPrivate, true},
+		{"GET /debug/routes", RouteAccessPrivate, true},
+		{"GET /raw", RouteAccessUnknown, false},
+	}
+	for _, tc := range cases {
+		entry, ok := table[tc.key]
+		if !ok {
+			t.Fatalf("%s missing from route table", tc.key)
+		}
+		if entry.Access != tc.access {
+			t.Errorf("%s access = %q, want %q", tc.key, entry.Access, tc.access)
+		}
+		if hasAuth(entry) != tc.auth {
+			t.Errorf("%s middleware = %v, auth recorded = %v, want %v", tc.key, entry.Middleware, !tc.auth, tc.auth)
+		}
+		if entry.Public != (tc.access == RouteAccessPublic) {
+			t.Errorf("%s public = %v", tc.key, entry.Public)
+		}
+	}
+}
+
+// TestRouteMiddlewareReadsRouteTree 读取 gin 路由树依赖其内部字段，gin 升级改名后该测试失败，而不是静默返回空的中间件链。
+func TestRouteMiddlewareReadsRouteTree(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(testRouteAuth)
+	engine.GET("/users/:id", testRouteAuth, testRouteHandler)
+	engine.POST("/users", testRouteHandler)
+
+	chains := routeMiddleware(engine)
+	for key, want := range map[string]int{"GET /users/:id": 2, "POST /users": 1} {
+		if got := len(chains[key]); got != want {
+			t.Fatalf("%s chain = %v, want %d middleware", key, chains[key], want)
+		}
+	}
+}
+
+func TestRouteChainFallsBackToGroupHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	group := engine.Group("/admin", testRouteAuth)
+	chain := routeChain(map[string][]string{}, "GET /admin/users", group)
+	if len(chain) != 1 || !strings.HasSuffix(chain[0], ".testRouteAuth") {
+		t.Fatalf("fallback chain = %v, want the group's middleware", chain)
+	}
+}
+
+// routeTrace 记录请求经过的中间件，处理函数把记录写回响应。
+func routeTrace(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("trace", append(c.GetStringSlice("trace"), name))
+		c.Next()
+	}
+}
+
+// routeTokenAuth 仅放行 X-Token 等于 token 的请求。
+func routeTokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Token") != token {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("trace", append(c.GetStringSlice("trace"), "auth:"+token))
+		c.Next()
+	}
+}
+
+func routeTraceHandler(c *gin.Context) {
+	c.String(http.StatusOK, strings.Join(c.GetStringSlice("trace"), ","))
+}
+
+func TestRouterGroupsThroughEngine(t *testing.T) {
+	router := NewRouter("/api", routeTrace("root"))
+	router.Auth(routeTokenAuth("user"))
+
+	v1 := router.Version("v1", routeTrace("v1"))
+	v1.Public(func(group *gin.RouterGroup) { group.GET("/ping", routeTraceHandler) })
+	v1.Private(func(group *gin.RouterGroup) { group.GET("/me", routeTraceHandler) })
+
+	admin := v1.Group("/admin").Auth(routeTokenAuth("admin"))
+	admin.Public(func(group *gin.RouterGroup) { group.GET("/login", routeTraceHandler) })
+	admin.Private(func(group *gin.RouterGroup) { group.GET("/stats", routeTraceHandler) })
+
+	router.Version("/v2").Private(func(group *gin.RouterGroup) { group.GET("/me", routeTraceHandler) })
+	router.Group("/open").Auth().Private(func(group *gin.RouterGroup) { group.GET("/data", routeTraceHandler) })
+
+	// 子分组创建之后追加的中间件同样作用于子分组
+	router.Use(routeTrace("late"))
+	v1.Use(routeTrace("v1-late"))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	router.Register(engine)
+
+	cases := []struct {
+		path, token string
+		status      int
+		trace       string
+	}{
+		{"/api/v1/ping", "", http.StatusOK, "root,late,v1,v1-late"},
+		{"/api/v1/me", "", http.StatusUnauthorized, ""},
+		{"/api/v1/me", "user", http.StatusOK, "root,late,v1,v1-late,auth:user"},
+		{"/api/v1/admin/login", "", http.StatusOK, "root,late,v1,v1-late"},
+		{"/api/v1/admin/stats", "user", http.StatusUnauthorized, ""},
+		{"/api/v1/admin/stats", "admin", http.StatusOK, "root,late,v1,v1-late,auth:admin"},
+		{"/api/v2/me", "", http.StatusUnauthorized, ""},
+		{"/api/v2/me", "user", http.StatusOK, "root,late,auth:user"},
+		{"/api/open/data", "", http.StatusOK, "root,late"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("X-Token", tc.token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.status || w.Body.String() != tc.trace {
+			t.Errorf("GET %s token %q = %d %q, want %d %q", tc.path, tc.token, w.Code, w.Body.String(), tc.status, tc.trace)
+		}
+	}
+}
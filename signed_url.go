@@ -0,0 +1,158 @@
+package gb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	SignedURLExpiresParam   = "expires"
+	SignedURLSignatureParam = "signature"
+	SignedURLIPClaim        = "ip" // 绑定客户端 IP 的声明，校验时与 c.ClientIP() 比较
+
+	signedURLClaimsKey = "signed_url_claims"
+)
+
+// URLSigner 使用 HMAC-SHA256 生成与校验带有效期的签名地址，只对路径、有效期与声明签名，与域名无关。
+type URLSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// InsURLSigner SignURL 与 MiddlewareSignedURL 默认使用的签名器，由 InitURLSigner 初始化。
+var InsURLSigner *URLSigner
+
+// NewURLSigner 创建签名器。
+func NewURLSigner(secret []byte) *URLSigner {
+	return &URLSigner{secret: secret, now: time.Now}
+}
+
+// InitURLSigner 初始化默认签名器。
+func InitURLSigner(secret []byte) {
+	if len(secret) < 16 {
+		panic("签名地址密钥长度不能小于16字节")
+	}
+	InsURLSigner = NewURLSigner(secret)
+}
+
+// SignURL 使用默认签名器为 path 生成在 expiry 内有效的地址，claims 以查询参数附加并参与签名。
+// path 可以是相对路径或完整地址，如 "/files/报告.pdf"、"https://cdn.example.com/a.png?size=small"。
+func SignURL(path string, expiry time.Duration, claims map[string]string) (string, error) {
+	if InsURLSigner == nil {
+		return "", errors.New("URLSigner为空,需要先使用gb.InitURLSigner()进行初始化")
+	}
+	return InsURLSigner.Sign(path, expiry, claims)
+}
+
+// Sign 生成签名地址，path 中已有的查询参数同样参与签名。
+func (s *URLSigner) Sign(path string, expiry time.Duration, claims map[string]string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", errors.New("URLSigner密钥为空")
+	}
+	if expiry <= 0 {
+		return "", errors.New("签名地址有效期需大于0")
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Del(SignedURLSignatureParam)
+	for key, value := range claims {
+		query.Set(key, value)
+	}
+	query.Set(SignedURLExpiresParam, strconv.FormatInt(s.now().Add(expiry).Unix(), 10))
+	query.Set(SignedURLSignatureParam, s.signature(u.EscapedPath(), query))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify 校验路径与查询参数，成功时返回除签名与有效期外的声明。
+func (s *URLSigner) Verify(escapedPath string, query url.Values, clientIP string) (map[string]string, error) {
+	if len(s.secret) == 0 {
+		return nil, ErrSignatureInvalid
+	}
+	signature := query.Get(SignedURLSignatureParam)
+	expires, err := strconv.ParseInt(query.Get(SignedURLExpiresParam), 10, 64)
+	if signature == "" || err != nil {
+		return nil, ErrSignatureInvalid
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(escapedPath, query))) {
+		return nil, ErrSignatureInvalid
+	}
+	if s.now().Unix() > expires {
+		return nil, ErrSignatureExpired
+	}
+	claims := make(map[string]string, len(query))
+	for key := range query {
+		if key != SignedURLSignatureParam && key != SignedURLExpiresParam {
+			claims[key] = query.Get(key)
+		}
+	}
+	if ip, ok := claims[SignedURLIPClaim]; ok && ip != clientIP {
+		return nil, ErrIPForbidden
+	}
+	return claims, nil
+}
+
+// signature 对 "路径\n排序后的查询参数（不含 signature）" 计算 HMAC。
+func (s *URLSigner) signature(escapedPath string, query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key != SignedURLSignatureParam {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(escapedPath)
+	for _, key := range keys {
+		for _, value := range query[key] {
+			b.WriteString("\n" + url.QueryEscape(key) + "=" + url.QueryEscape(value))
+		}
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(b.String()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// MiddlewareSignedURL 校验签名地址，未传 signer 时使用 InsURLSigner；校验通过后可用 GetSignedURLClaims 读取声明。
+// 签名无效返回 ErrSignatureInvalid，过期返回 ErrSignatureExpired，IP 不匹配返回 ErrIPForbidden。
+func MiddlewareSignedURL(signer ...*URLSigner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := InsURLSigner
+		if len(signer) > 0 && signer[0] != nil {
+			s = signer[0]
+		}
+		if s == nil {
+			ResponseError(c, ErrSignatureInvalid)
+			c.Abort()
+			return
+		}
+		claims, err := s.Verify(c.Request.URL.EscapedPath(), c.Request.URL.Query(), c.ClientIP())
+		if err != nil {
+			ResponseError(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(signedURLClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// GetSignedURLClaims 返回 MiddlewareSignedURL 校验通过的声明。
+func GetSignedURLClaims(c *gin.Context) map[string]string {
+	if claims, ok := c.Get(signedURLClaimsKey); ok {
+		return claims.(map[string]string)
+	}
+	return map[string]string{}
+}
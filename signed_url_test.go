@@ -0,0 +1,88 @@
+package gb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSignedURLTestEngine(signer *URLSigner) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/files/:name", MiddlewareSignedURL(signer), func(c *gin.Context) {
+		ResponseSuccess(c, GetSignedURLClaims(c))
+	})
+	return engine
+}
+
+func getSignedURL(engine *gin.Engine, target string) Response {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	var resp Response
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestSignedURLRoundTrip(t *testing.T) {
+	signer := NewURLSigner([]byte("0123456789abcdef"))
+	signed, err := signer.Sign("/files/报告.pdf?size=small", time.Minute, map[string]string{SignedURLIPClaim: "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := getSignedURL(newSignedURLTestEngine(signer), signed)
+	claims, _ := resp.Data.(map[string]any)
+	if resp.Code != http.StatusOK || claims["size"] != "small" || claims[SignedURLIPClaim] != "192.0.2.1" {
+		t.Fatalf("signed url %s = %+v", signed, resp)
+	}
+}
+
+func TestSignedURLRejections(t *testing.T) {
+	now := time.Now()
+	signer := NewURLSigner([]byte("0123456789abcdef"))
+	signer.now = func() time.Time { return now }
+	engine := newSignedURLTestEngine(signer)
+
+	signed, err := signer.Sign("/files/a.pdf?size=small", time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(signed)
+	query := u.Query()
+	query.Set("size", "large")
+	tamperedQuery := u.Path + "?" + query.Encode()
+	tamperedPath := "/files/b.pdf?" + u.RawQuery
+
+	otherIP, err := signer.Sign("/files/a.pdf", time.Minute, map[string]string{SignedURLIPClaim: "198.51.100.7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		target string
+		want   *AppError
+	}{
+		{"tampered query", tamperedQuery, ErrSignatureInvalid},
+		{"tampered path", tamperedPath, ErrSignatureInvalid},
+		{"missing signature", "/files/a.pdf?size=small", ErrSignatureInvalid},
+		{"ip mismatch", otherIP, ErrIPForbidden},
+	}
+	for _, tc := range cases {
+		if resp := getSignedURL(engine, tc.target); resp.Code != tc.want.Code {
+			t.Errorf("%s: %s = %+v, want %s", tc.name, tc.target, resp, tc.want.Message)
+		}
+	}
+
+	if resp := getSignedURL(engine, signed); resp.Code != http.StatusOK {
+		t.Fatalf("valid url = %+v", resp)
+	}
+	now = now.Add(2 * time.Minute)
+	if resp := getSignedURL(engine, signed); resp.Code != ErrSignatureExpired.Code {
+		t.Fatalf("expired url = %+v, want ErrSignatureExpired", resp)
+	}
+}
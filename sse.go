@@ -0,0 +1,380 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const LastEventIDHeader = "Last-Event-ID"
+
+// SSEEvent 一条 server-sent event。Data 为 string、[]byte 时原样输出，其他类型序列化为 JSON。
+type SSEEvent struct {
+	ID    string        `json:"id,omitempty"`
+	Event string        `json:"event,omitempty"`
+	Data  any           `json:"data,omitempty"`
+	Retry time.Duration `json:"-"`
+}
+
+// SSEReplayBuffer 保存已发送的事件，用于客户端携带 Last-Event-ID 重连后补发。
+type SSEReplayBuffer interface {
+	// Append 保存事件并返回分配的事件 ID
+	Append(ctx context.Context, stream string, event SSEEvent) (string, error)
+	// Since 返回 lastID 之后的事件，lastID 已不在缓冲区时返回缓冲区中的全部事件
+	Since(ctx context.Context, stream string, lastID string) ([]SSEEvent, error)
+}
+
+type SSEConfig struct {
+	Stream    string          // 事件流名称，补发时用于区分不同的流，如 "export:" + 任务 ID
+	Replay    SSEReplayBuffer // 补发缓冲区，为空时不支持 Last-Event-ID 续传
+	Heartbeat time.Duration   // 心跳间隔，默认 15 秒，小于 0 表示不发送心跳
+	Retry     time.Duration   // 建议客户端的重连间隔，为 0 时不下发
+}
+
+// SSEStream 一个 SSE 连接，Send 可在多个协程中并发调用。
+// c.Writer 指向池化的 gin.Context 内部，处理函数返回后会被下一个请求复用，因此不启动后台协程：
+// 心跳只在 Wait 中由处理函数所在协程发送，Close 返回后不会再写入。
+type SSEStream struct {
+	ctx         context.Context
+	writer      gin.ResponseWriter
+	lastEventID string
+	config      SSEConfig
+	mu          sync.Mutex
+	done        chan struct{}
+	once        sync.Once
+	err         error
+}
+
+// NewSSE 写出 SSE 响应头，若客户端携带 Last-Event-ID 且配置了 Replay，会先补发遗漏的事件。
+// 由其他协程推送事件时，处理函数调用 Wait 阻塞并发送心跳，推送结束后调用 Close；
+// 处理函数返回前需确保 Wait 已返回或已调用 Close，之后的 Send 返回错误。
+func NewSSE(c *gin.Context, config ...SSEConfig) (*SSEStream, error) {
+	var cfg SSEConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Heartbeat == 0 {
+		cfg.Heartbeat = 15 * time.Second
+	}
+	if _, ok := c.Writer.(http.Flusher); !ok {
+		return nil, errors.New("ResponseWriter不支持Flush")
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	setTraceHeaders(c)
+	// 长连接不受 http.Server 的 WriteTimeout 限制
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Status(http.StatusOK)
+
+	s := &SSEStream{
+		ctx:         c.Request.Context(),
+		writer:      c.Writer,
+		lastEventID: c.GetHeader(LastEventIDHeader),
+		config:      cfg,
+		done:        make(chan struct{}),
+	}
+	if cfg.Retry > 0 {
+		if err := s.write(fmt.Sprintf("retry: %d\n\n", cfg.Retry.Milliseconds())); err != nil {
+			return nil, err
+		}
+	} else if err := s.write(": connected\n\n"); err != nil {
+		return nil, err
+	}
+
+	if s.lastEventID != "" && cfg.Replay != nil {
+		events, err := cfg.Replay.Since(s.ctx, cfg.Stream, s.lastEventID)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if err := s.write(formatSSEEvent(event)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// Send 发送事件，配置了 Replay 且事件未指定 ID 时由 Replay 分配 ID。
+func (s *SSEStream) Send(event SSEEvent) error {
+	select {
+	case <-s.done:
+		return s.Err()
+	default:
+	}
+	if s.config.Replay != nil && event.ID == "" {
+		id, err := s.config.Replay.Append(s.ctx, s.config.Stream, event)
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+	return s.write(formatSSEEvent(event))
+}
+
+// SendData 发送只包含 event 与 data 的事件。
+func (s *SSEStream) SendData(event string, data any) error {
+	return s.Send(SSEEvent{Event: event, Data: data})
+}
+
+// Done 调用 Close、Wait 检测到客户端断开或写入失败后关闭。
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err 返回连接结束的原因。
+func (s *SSEStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close 结束连接并使 Wait 返回，正在进行的写入完成后才返回，之后的 Send 返回错误。
+func (s *SSEStream) Close() {
+	s.close(context.Canceled)
+}
+
+// LastEventID 返回客户端重连时携带的 Last-Event-ID。
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Wait 在处理函数所在协程中发送心跳，直到调用 Close、客户端断开或写入失败，返回时连接已结束。
+// 调用 Close 结束时返回 nil，否则返回结束原因。
+func (s *SSEStream) Wait() error {
+	defer s.Close()
+	var tick <-chan time.Time
+	if s.config.Heartbeat > 0 {
+		ticker := time.NewTicker(s.config.Heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.done:
+			if err := s.Err(); !errors.Is(err, context.Canceled) || s.ctx.Err() != nil {
+				return err
+			}
+			return nil
+		case <-s.ctx.Done():
+			s.close(s.ctx.Err())
+		case <-tick:
+			_ = s.write(": ping\n\n")
+		}
+	}
+}
+
+// write 方法用于处理write相关逻辑。
+func (s *SSEStream) write(frame string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	// 客户端已断开时不再写入
+	if err := s.ctx.Err(); err != nil {
+		s.setErr(err)
+		return err
+	}
+	if _, err := s.writer.WriteString(frame); err != nil {
+		s.setErr(err)
+		return err
+	}
+	s.writer.Flush()
+	return nil
+}
+
+// close 方法用于处理close相关逻辑。
+func (s *SSEStream) close(err error) {
+	s.mu.Lock()
+	s.setErr(err)
+	s.mu.Unlock()
+}
+
+// setErr 记录第一个错误并关闭 done，调用方需持有锁。
+func (s *SSEStream) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+	s.once.Do(func() { close(s.done) })
+}
+
+// formatSSEEvent 按 text/event-stream 格式编码事件，多行数据拆分为多个 data 字段。
+func formatSSEEvent(event SSEEvent) string {
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + stripSSENewline(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + stripSSENewline(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	data := sseData(event.Data)
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// sseData 函数用于处理sseData相关逻辑。
+func sseData(data any) string {
+	switch v := data.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case json.RawMessage:
+		return string(v)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(raw)
+	}
+}
+
+// stripSSENewline 函数用于处理stripSSENewline相关逻辑。
+func stripSSENewline(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// isEventStream 判断响应是否为 SSE。
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(strings.ToLower(header.Get("Content-Type")), "text/event-stream")
+}
+
+type memorySSEReplayBuffer struct {
+	size    int
+	mu      sync.Mutex
+	seq     uint64
+	streams map[string][]SSEEvent
+}
+
+// NewMemorySSEReplayBuffer 进程内补发缓冲区，每个流保留最近 size 条事件（默认 100），仅适用于单实例。
+func NewMemorySSEReplayBuffer(size int) SSEReplayBuffer {
+	if size <= 0 {
+		size = 100
+	}
+	return &memorySSEReplayBuffer{size: size, streams: make(map[string][]SSEEvent)}
+}
+
+// Append 方法用于处理Append相关逻辑。
+func (b *memorySSEReplayBuffer) Append(_ context.Context, stream string, event SSEEvent) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	event.ID = strconv.FormatUint(b.seq, 10)
+	events := append(b.streams[stream], event)
+	if len(events) > b.size {
+		events = events[len(events)-b.size:]
+	}
+	b.streams[stream] = events
+	return event.ID, nil
+}
+
+// Since 方法用于处理Since相关逻辑。
+func (b *memorySSEReplayBuffer) Since(_ context.Context, stream string, lastID string) ([]SSEEvent, error) {
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []SSEEvent
+	for _, event := range b.streams[stream] {
+		if id, _ := strconv.ParseUint(event.ID, 10, 64); id > last {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+type redisSSEReplayBuffer struct {
+	prefix string
+	maxLen int64
+	ttl    time.Duration
+}
+
+// NewRedisSSEReplayBuffer 基于 redis stream 的补发缓冲区，事件 ID 即 stream ID，多实例共享。
+// maxLen 为每个流保留的近似条数（默认 1000），ttl 为流的过期时间（默认 24 小时）。
+func NewRedisSSEReplayBuffer(prefix string, maxLen int64, ttl time.Duration) SSEReplayBuffer {
+	if prefix == "" {
+		prefix = "gb:sse"
+	}
+	if maxLen <= 0 {
+		maxLen = 1000
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &redisSSEReplayBuffer{prefix: prefix, maxLen: maxLen, ttl: ttl}
+}
+
+// Append 方法用于处理Append相关逻辑。
+func (b *redisSSEReplayBuffer) Append(ctx context.Context, stream string, event SSEEvent) (string, error) {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return "", redisClientNilErr()
+	}
+	key := b.key(stream)
+	pipe := InsRedis.TxPipeline()
+	add := pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]any{"event": event.Event, "data": sseData(event.Data)},
+	})
+	pipe.Expire(ctx, key, b.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return add.Val(), nil
+}
+
+// Since 方法用于处理Since相关逻辑。
+func (b *redisSSEReplayBuffer) Since(ctx context.Context, stream string, lastID string) ([]SSEEvent, error) {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return nil, redisClientNilErr()
+	}
+	messages, err := InsRedis.XRange(ctx, b.key(stream), "("+lastID, "+").Result()
+	if err != nil {
+		// 非法的 stream ID 视为没有可补发的事件
+		if strings.Contains(err.Error(), "Invalid stream ID") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	events := make([]SSEEvent, 0, len(messages))
+	for _, message := range messages {
+		event, _ := message.Values["event"].(string)
+		data, _ := message.Values["data"].(string)
+		events = append(events, SSEEvent{ID: message.ID, Event: event, Data: data})
+	}
+	return events, nil
+}
+
+// key 方法用于处理key相关逻辑。
+func (b *redisSSEReplayBuffer) key(stream string) string {
+	return b.prefix + ":" + stream
+}
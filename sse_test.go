@@ -0,0 +1,143 @@
+package gb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSSEReplayAfterReconnect(t *testing.T) {
+	replay := NewMemorySSEReplayBuffer(10)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/events", func(c *gin.Context) {
+		s, err := NewSSE(c, SSEConfig{Stream: "export", Replay: replay, Heartbeat: -1})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer s.Close()
+		if s.LastEventID() == "" {
+			for i := 0; i < 3; i++ {
+				s.SendData("progress", i)
+			}
+		}
+	})
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	read := func(lastEventID string) []string {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set(LastEventIDHeader, lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var data []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				data = append(data, line)
+			}
+		}
+		return data
+	}
+
+	if got := strings.Join(read(""), ","); got != "0,1,2" {
+		t.Fatalf("events = %q, want 0,1,2", got)
+	}
+	if got := strings.Join(read("1"), ","); got != "1,2" {
+		t.Fatalf("replayed events = %q, want 1,2", got)
+	}
+}
+
+func TestSSEWaitSendsHeartbeatUntilClose(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	waitErr := make(chan error, 1)
+	engine.GET("/events", func(c *gin.Context) {
+		s, err := NewSSE(c, SSEConfig{Heartbeat: 5 * time.Millisecond})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			s.SendData("done", "ok")
+			s.Close()
+		}()
+		waitErr <- s.Wait()
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if err := <-waitErr; err != nil {
+		t.Fatalf("Wait = %v, want nil after Close", err)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, ": ping") || !strings.HasSuffix(body, "event: done\ndata: ok\n\n") {
+		t.Fatalf("unexpected stream: %q", body)
+	}
+}
+
+func TestSSEWaitReturnsOnClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	waitErr := make(chan error, 1)
+	engine.GET("/events", func(c *gin.Context) {
+		s, err := NewSSE(c, SSEConfig{Heartbeat: -1})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		waitErr <- s.Wait()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+	if err := <-waitErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+}
+
+// 处理函数未调用 Close 直接返回时，请求 context 不会结束（如 httptest 的请求），
+// 不能有任何写入进入已归还到池中的 gin.Context。
+func TestSSEHandlerReturnsWithoutClose(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/events", func(c *gin.Context) {
+		if _, err := NewSSE(c, SSEConfig{Heartbeat: time.Millisecond}); err != nil {
+			t.Error(err)
+		}
+	})
+	engine.GET("/plain", func(c *gin.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.String(http.StatusOK, "ok")
+	})
+
+	first := httptest.NewRecorder()
+	engine.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/events", nil))
+	written := first.Body.String()
+
+	second := httptest.NewRecorder()
+	engine.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	if got := second.Body.String(); got != "ok" {
+		t.Fatalf("next request body = %q, heartbeat leaked into a reused context", got)
+	}
+	if got := first.Body.String(); got != written {
+		t.Fatalf("stream kept writing after the handler returned: %q", got)
+	}
+}
@@ -0,0 +1,281 @@
+package gb
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrObjectNotFound 对象不存在，Storage 的实现在 Get、Stat 找不到对象时返回。
+var ErrObjectNotFound = errors.New("文件不存在")
+
+// StorageObject 对象的元信息。
+type StorageObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Storage 文件存储，key 使用 "/" 分隔的相对路径。
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (StorageObject, error)
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, StorageObject, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Stat 返回对象元信息
+	Stat(ctx context.Context, key string) (StorageObject, error)
+	// PresignedURL 生成在 expiry 内有效的访问地址，method 为 GET 或 PUT
+	PresignedURL(ctx context.Context, method, key string, expiry time.Duration) (string, error)
+}
+
+type LocalStorageConfig struct {
+	Root    string // 存储根目录
+	BaseURL string // 访问地址前缀，如 "https://api.example.com/files"，对应挂载 Handler 的路由
+	Secret  []byte // PresignedURL 的签名密钥，为空时 PresignedURL 返回错误
+}
+
+// LocalStorage 本地磁盘存储。
+type LocalStorage struct {
+	config LocalStorageConfig
+}
+
+// NewLocalStorage 创建本地磁盘存储，Root 不存在时自动创建。
+func NewLocalStorage(config LocalStorageConfig) (*LocalStorage, error) {
+	if config.Root == "" {
+		return nil, errors.New("LocalStorageConfig.Root为空")
+	}
+	root, err := filepath.Abs(config.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	config.Root = root
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &LocalStorage{config: config}, nil
+}
+
+// Put 先写入同目录下的临时文件再重命名，写入中途失败不会留下不完整的文件。
+func (s *LocalStorage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (StorageObject, error) {
+	name, err := s.filename(key)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return StorageObject{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return StorageObject{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), ctxReader{ctx: ctx, r: reader})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return StorageObject{}, err
+	}
+	if size >= 0 && written != size {
+		return StorageObject{}, fmt.Errorf("文件大小不一致: 期望 %d, 实际 %d", size, written)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return StorageObject{}, err
+	}
+	if contentType == "" {
+		contentType = localContentType(name)
+	}
+	return StorageObject{
+		Key:          s.cleanKey(key),
+		Size:         written,
+		ContentType:  contentType,
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		LastModified: Now(),
+	}, nil
+}
+
+// Get 方法用于处理Get相关逻辑。
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, StorageObject, error) {
+	name, err := s.filename(key)
+	if err != nil {
+		return nil, StorageObject{}, err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, StorageObject{}, ErrObjectNotFound
+		}
+		return nil, StorageObject{}, err
+	}
+	object, err := s.stat(key, name, file)
+	if err != nil {
+		file.Close()
+		return nil, StorageObject{}, err
+	}
+	return file, object, nil
+}
+
+// Delete 方法用于处理Delete相关逻辑。
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.filename(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Stat 方法用于处理Stat相关逻辑。
+func (s *LocalStorage) Stat(ctx context.Context, key string) (StorageObject, error) {
+	name, err := s.filename(key)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	return s.stat(key, name, nil)
+}
+
+// PresignedURL 使用 URLSigner 生成签名地址，由 Handler 校验后提供下载或上传。
+func (s *LocalStorage) PresignedURL(ctx context.Context, method, key string, expiry time.Duration) (string, error) {
+	if len(s.config.Secret) == 0 {
+		return "", errors.New("LocalStorageConfig.Secret为空")
+	}
+	return NewURLSigner(s.config.Secret).Sign(s.config.BaseURL+"/"+escapeKey(s.cleanKey(key)), expiry, map[string]string{
+		"method": strings.ToUpper(method),
+	})
+}
+
+// Handler 校验 PresignedURL 的签名，GET/HEAD 支持 Range 下载，PUT 写入请求体。
+// 需挂载到带 *key 参数的路由上，如 r.Any("/files/*key", storage.Handler())，且路由路径需与 BaseURL 的路径一致。
+func (s *LocalStorage) Handler() gin.HandlerFunc {
+	signer := NewURLSigner(s.config.Secret)
+	return func(c *gin.Context) {
+		key := s.cleanKey(c.Param("key"))
+		method := c.Request.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		claims, err := signer.Verify(c.Request.URL.EscapedPath(), c.Request.URL.Query(), c.ClientIP())
+		if err == nil && claims["method"] != method {
+			err = ErrSignatureInvalid
+		}
+		if err != nil {
+			ResponseError(c, err)
+			c.Abort()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			ResponseStorageFile(c, s, key, "", "inline")
+		case http.MethodPut:
+			object, err := s.Put(c.Request.Context(), key, c.Request.Body, c.Request.ContentLength, c.ContentType())
+			if err != nil {
+				ResponseError(c, err)
+				return
+			}
+			ResponseSuccess(c, object)
+		default:
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// stat 方法用于处理stat相关逻辑。
+func (s *LocalStorage) stat(key, name string, file *os.File) (StorageObject, error) {
+	var (
+		info os.FileInfo
+		err  error
+	)
+	if file != nil {
+		info, err = file.Stat()
+	} else {
+		info, err = os.Stat(name)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return StorageObject{}, ErrObjectNotFound
+		}
+		return StorageObject{}, err
+	}
+	if info.IsDir() {
+		return StorageObject{}, ErrObjectNotFound
+	}
+	return StorageObject{
+		Key:          s.cleanKey(key),
+		Size:         info.Size(),
+		ContentType:  localContentType(name),
+		ETag:         fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// cleanKey 去除 key 中的 "." 与 ".."，保证不会越出根目录。
+func (s *LocalStorage) cleanKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+// filename 方法用于处理filename相关逻辑。
+func (s *LocalStorage) filename(key string) (string, error) {
+	key = s.cleanKey(key)
+	if key == "" {
+		return "", errors.New("文件key为空")
+	}
+	return filepath.Join(s.config.Root, filepath.FromSlash(key)), nil
+}
+
+// localContentType 先按扩展名判断，未知时读取文件头识别。
+func localContentType(name string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	contentType, err := sniffFile(name)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// escapeKey 按路径段转义 key。
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// ctxReader 在 context 取消后停止读取。
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read 方法用于处理Read相关逻辑。
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,331 @@
+package gb
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3DateLayout      = "20060102T150405Z"
+)
+
+type S3StorageConfig struct {
+	Endpoint        string       // 服务地址，如 https://s3.ap-east-1.amazonaws.com、https://oss-cn-hangzhou.aliyuncs.com、http://127.0.0.1:9000
+	Region          string       // 区域，默认 us-east-1
+	Bucket          string       // 桶名
+	AccessKeyID     string       // 访问密钥 ID
+	SecretAccessKey string       // 访问密钥
+	PathStyle       bool         // 使用 path-style 地址（endpoint/bucket/key），MinIO 等自建服务通常需要开启
+	Client          *http.Client // HTTP 客户端，默认 http.DefaultClient
+	Now             func() time.Time
+}
+
+// S3Storage S3 兼容的对象存储，使用 AWS Signature V4 签名，兼容 AWS S3、MinIO、阿里云 OSS、腾讯云 COS 等。
+type S3Storage struct {
+	config   S3StorageConfig
+	endpoint *url.URL
+}
+
+// NewS3Storage 创建 S3 兼容存储。
+func NewS3Storage(config S3StorageConfig) (*S3Storage, error) {
+	if config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3StorageConfig的Bucket、AccessKeyID、SecretAccessKey不能为空")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3StorageConfig.Endpoint无效: %q", config.Endpoint)
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &S3Storage{config: config, endpoint: endpoint}, nil
+}
+
+// Put 上传对象，S3 不支持分块传输编码，size 需为实际大小。
+func (s *S3Storage) Put(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (StorageObject, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), reader)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	switch {
+	case size == 0:
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	case size > 0:
+		req.ContentLength = size
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.do(req)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	resp.Body.Close()
+	return StorageObject{
+		Key:          key,
+		Size:         size,
+		ContentType:  contentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: s.config.Now(),
+	}, nil
+}
+
+// Get 方法用于处理Get相关逻辑。
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, StorageObject, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, StorageObject{}, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, StorageObject{}, err
+	}
+	return resp.Body, s3Object(key, resp), nil
+}
+
+// Delete 方法用于处理Delete相关逻辑。
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Stat 方法用于处理Stat相关逻辑。
+func (s *S3Storage) Stat(ctx context.Context, key string) (StorageObject, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return StorageObject{}, err
+	}
+	resp.Body.Close()
+	return s3Object(key, resp), nil
+}
+
+// PresignedURL 生成查询参数签名的地址，expiry 最长 7 天。
+func (s *S3Storage) PresignedURL(ctx context.Context, method, key string, expiry time.Duration) (string, error) {
+	if expiry <= 0 || expiry > 7*24*time.Hour {
+		return "", errors.New("PresignedURL有效期需在0到7天之间")
+	}
+	u := s.objectURL(key)
+	now := s.config.Now().UTC()
+	amzDate := now.Format(s3DateLayout)
+	scope := s.scope(now)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = s3CanonicalQuery(query)
+
+	header := http.Header{}
+	header.Set("Host", u.Host)
+	canonical, _ := s3CanonicalRequest(strings.ToUpper(method), u, header, s3UnsignedPayload)
+	signature := s.signature(now, amzDate, scope, canonical)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// do 签名并发送请求，非 2xx 响应转换为错误。
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %d %s: %s", req.Method, req.URL.Path, resp.StatusCode, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// sign 使用请求头签名，请求体不参与签名（UNSIGNED-PAYLOAD），以便流式上传。
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.config.Now().UTC()
+	amzDate := now.Format(s3DateLayout)
+	scope := s.scope(now)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	header := req.Header.Clone()
+	header.Set("Host", req.URL.Host)
+	canonical, signedHeaders := s3CanonicalRequest(req.Method, req.URL, header, s3UnsignedPayload)
+	signature := s.signature(now, amzDate, scope, canonical)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// signature 方法用于处理signature相关逻辑。
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hash[:])}, "\n")
+	key := s3HMAC([]byte("AWS4"+s.config.SecretAccessKey), now.Format("20060102"))
+	key = s3HMAC(key, s.config.Region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	return hex.EncodeToString(s3HMAC(key, stringToSign))
+}
+
+// scope 方法用于处理scope相关逻辑。
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// objectURL 方法用于处理objectURL相关逻辑。
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	key = strings.TrimPrefix(key, "/")
+	basePath := strings.TrimRight(u.Path, "/")
+	if s.config.PathStyle {
+		u.Path = basePath + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	// 请求行使用与签名相同的编码，否则 "+" 等字符按原样发送，服务端计算出的签名不一致
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = ""
+	return &u
+}
+
+// s3CanonicalRequest 返回规范请求与参与签名的请求头列表。
+func s3CanonicalRequest(method string, u *url.URL, header http.Header, payloadHash string) (string, string) {
+	names := make([]string, 0, len(header))
+	values := make(map[string]string, len(header))
+	for name, value := range header {
+		lower := strings.ToLower(name)
+		if lower != "host" && lower != "content-type" && lower != "content-md5" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		names = append(names, lower)
+		trimmed := make([]string, len(value))
+		for i, v := range value {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := s3EscapePath(u.Path)
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	return strings.Join([]string{
+		method,
+		canonicalURI,
+		s3CanonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// s3CanonicalQuery 按 key 排序并使用 RFC 3986 编码。
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath 函数用于处理s3EscapePath相关逻辑。
+func s3EscapePath(p string) string {
+	return s3Escape(p, false)
+}
+
+// s3Escape 只保留 RFC 3986 的非保留字符，encodeSlash 为 false 时保留 "/"。
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// s3HMAC 函数用于处理s3HMAC相关逻辑。
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Object 函数用于处理s3Object相关逻辑。
+func s3Object(key string, resp *http.Response) StorageObject {
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return StorageObject{
+		Key:          key,
+		Size:         resp.ContentLength,
+		ContentType:  resp.Header.Get("Content-Type"),
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: lastModified,
+	}
+}
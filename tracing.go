@@ -0,0 +1,340 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracerName = "github.com/loveyu233/gb"
+
+type TracingExporter string
+
+const (
+	TracingExporterOTLP   TracingExporter = "otlp"   // OTLP/HTTP，默认地址 localhost:4318，可用 OTEL_EXPORTER_OTLP_* 环境变量覆盖
+	TracingExporterStdout TracingExporter = "stdout" // 输出到标准输出
+	TracingExporterFile   TracingExporter = "file"   // 以 JSON 行写入 FilePath
+)
+
+type TracingConfig struct {
+	ServiceName  string                // 服务名
+	Exporter     TracingExporter       // 导出方式，默认 OTLP
+	Endpoint     string                // OTLP 地址，如 otel-collector:4318
+	Insecure     bool                  // OTLP 是否使用 http
+	Headers      map[string]string     // OTLP 请求头（如鉴权）
+	FilePath     string                // file 导出的文件路径
+	SampleRatio  float64               // 采样率，默认 1（全部采样）；上游已采样的请求始终跟随上游
+	SpanExporter sdktrace.SpanExporter // 自定义导出器（如 tracetest.NewInMemoryExporter），设置后忽略 Exporter
+	Attributes   []attribute.KeyValue  // 附加的资源属性，如环境、版本
+}
+
+// tracer 返回 gb 使用的 tracer，未调用 InitTracing 时为 noop，仅传播 trace id。
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InitTracing 初始化 OpenTelemetry：设置全局 TracerProvider 与 W3C traceparent/tracestate 传播。
+// 返回的 shutdown 需在退出前调用以导出剩余的 span。
+func InitTracing(config TracingConfig) (shutdown func(context.Context) error, err error) {
+	if config.ServiceName == "" {
+		return nil, errors.New("TracingConfig.ServiceName不能为空")
+	}
+	if config.SampleRatio <= 0 {
+		config.SampleRatio = 1
+	}
+
+	exporter := config.SpanExporter
+	var closeFile func() error
+	if exporter == nil {
+		switch config.Exporter {
+		case TracingExporterStdout:
+			exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		case TracingExporterFile:
+			if config.FilePath == "" {
+				return nil, errors.New("TracingConfig.FilePath不能为空")
+			}
+			file, openErr := os.OpenFile(config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if openErr != nil {
+				return nil, openErr
+			}
+			closeFile = file.Close
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		case TracingExporterOTLP, "":
+			opts := make([]otlptracehttp.Option, 0, 3)
+			if config.Endpoint != "" {
+				opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+			}
+			if config.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+			if len(config.Headers) > 0 {
+				opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+			}
+			exporter, err = otlptracehttp.New(context.Background(), opts...)
+		default:
+			return nil, fmt.Errorf("不支持的导出方式: %s", config.Exporter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		append([]attribute.KeyValue{attribute.String("service.name", config.ServiceName)}, config.Attributes...)...,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	// 标准输出、文件与自定义导出器同步导出，便于测试中直接断言
+	spanProcessor := sdktrace.WithBatcher(exporter)
+	if config.SpanExporter != nil || config.Exporter == TracingExporterStdout || config.Exporter == TracingExporterFile {
+		spanProcessor = sdktrace.WithSyncer(exporter)
+	}
+	provider := sdktrace.NewTracerProvider(
+		spanProcessor,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newTracingSampler(config.SampleRatio)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(traceContextPropagator)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			err = errors.Join(err, closeFile())
+		}
+		return err
+	}, nil
+}
+
+// newTracingSampler 跟随上游 traceparent 的采样决定，根 span 与旧版 Trace-Id 请求按 ratio 采样。
+func newTracingSampler(ratio float64) sdktrace.Sampler {
+	root := sdktrace.TraceIDRatioBased(ratio)
+	return sdktrace.ParentBased(root, sdktrace.WithRemoteParentNotSampled(legacyTraceSampler{root: root}))
+}
+
+// legacyTraceSampler 远端父节点未采样时，来自旧版 Trace-Id 请求头的按 root 采样，其余不采样。
+type legacyTraceSampler struct {
+	root sdktrace.Sampler
+}
+
+// ShouldSample 方法用于处理ShouldSample相关逻辑。
+func (s legacyTraceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if legacy, _ := p.ParentContext.Value(legacyTraceContextKey{}).(bool); legacy {
+		return s.root.ShouldSample(p)
+	}
+	return sdktrace.NeverSample().ShouldSample(p)
+}
+
+// Description 方法用于处理Description相关逻辑。
+func (s legacyTraceSampler) Description() string {
+	return "LegacyTraceID{" + s.root.Description() + "}"
+}
+
+// traceContextPropagator 始终支持 W3C traceparent，即使未调用 InitTracing。
+var traceContextPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// GetTraceID 返回 context 中的 W3C trace id，不存在时返回空字符串。
+func GetTraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// StartSpan 在请求 context 下创建子 span，调用方负责 span.End()。
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, opts...)
+}
+
+// endSpan 函数用于处理endSpan相关逻辑。
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// finishGinSpan 记录响应状态，HTTP 5xx 或业务 5xxxxx 错误码标记为失败。
+func finishGinSpan(c *gin.Context, span trace.Span) {
+	status := c.Writer.Status()
+	respStatus := c.GetInt("resp-status")
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", status),
+		attribute.Int("gb.resp_status", respStatus),
+	)
+	if route := c.FullPath(); route != "" {
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttributes(attribute.String("http.route", route))
+	}
+	if status >= 500 || respStatus >= ErrServerBusy.Code {
+		span.SetStatus(codes.Error, c.GetString("resp-msg"))
+	}
+	span.End()
+}
+
+const gormSpanKey = "gb:otel_span"
+
+// GormTracing 为 GORM 注册链路追踪回调，可作为 InitGormDB 的 opt 传入：InitGormDB(cfg, logger, GormTracing)。
+// 查询需使用 InsDB.WithGinContext(c) 或 WithContext(ctx) 才能挂到当前请求的链路上。
+func GormTracing(db *gorm.DB) error {
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			ctx := tx.Statement.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, span := tracer().Start(ctx, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attribute.String("db.system", "mysql"), attribute.String("db.operation", operation)))
+			tx.Statement.Context = ctx
+			tx.InstanceSet(gormSpanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		span.SetAttributes(
+			attribute.String("db.statement", tx.Statement.SQL.String()),
+			attribute.String("db.sql.table", tx.Statement.Table),
+			attribute.Int64("db.rows_affected", tx.RowsAffected),
+		)
+		err := tx.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		endSpan(span, err)
+	}
+
+	callback := db.Callback()
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range register {
+		if err := r.before("gb:otel_before_"+r.operation, before(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("gb:otel_after_"+r.operation, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redisTracingHook 为每条 redis 命令（或 pipeline）创建子 span。
+type redisTracingHook struct{}
+
+// DialHook 方法用于处理DialHook相关逻辑。
+func (redisTracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 方法用于处理ProcessHook相关逻辑。
+func (redisTracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmd)
+		}
+		ctx, span := tracer().Start(ctx, "redis."+cmd.FullName(), trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "redis"), attribute.String("db.operation", cmd.FullName())))
+		err := next(ctx, cmd)
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		endSpan(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 方法用于处理ProcessPipelineHook相关逻辑。
+func (redisTracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmds)
+		}
+		ctx, span := tracer().Start(ctx, "redis.pipeline", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "redis"), attribute.Int("db.redis.num_cmd", len(cmds))))
+		err := next(ctx, cmds)
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		endSpan(span, err)
+		return err
+	}
+}
+
+type restyParentContextKey struct{}
+
+// restyTracingBeforeRequest 为 R() 发出的请求创建 client span，并写入 traceparent 请求头。
+// 重试时每次尝试都是原始 context 下的兄弟 span。
+func restyTracingBeforeRequest(_ *resty.Client, r *resty.Request) error {
+	parent := r.Context()
+	if p, ok := parent.Value(restyParentContextKey{}).(context.Context); ok {
+		// 上一次尝试因网络错误未经过 OnAfterResponse，在此结束
+		if span := trace.SpanFromContext(parent); span.IsRecording() {
+			span.SetStatus(codes.Error, "retry")
+			span.End()
+		}
+		parent = p
+	}
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		return nil
+	}
+	ctx, _ := tracer().Start(parent, "HTTP "+r.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", r.Method), attribute.String("url.full", r.URL)))
+	ctx = context.WithValue(ctx, restyParentContextKey{}, parent)
+	r.SetContext(ctx)
+	traceContextPropagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	return nil
+}
+
+// restyTracingAfterResponse 函数用于处理restyTracingAfterResponse相关逻辑。
+func restyTracingAfterResponse(_ *resty.Client, resp *resty.Response) error {
+	span := trace.SpanFromContext(resp.Request.Context())
+	if _, ok := resp.Request.Context().Value(restyParentContextKey{}).(context.Context); !ok {
+		return nil
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+	if resp.StatusCode() >= 500 {
+		span.SetStatus(codes.Error, strconv.Itoa(resp.StatusCode()))
+	}
+	span.End()
+	return nil
+}
+
+// restyTracingOnError 函数用于处理restyTracingOnError相关逻辑。
+func restyTracingOnError(r *resty.Request, err error) {
+	if _, ok := r.Context().Value(restyParentContextKey{}).(context.Context); !ok {
+		return
+	}
+	endSpan(trace.SpanFromContext(r.Context()), err)
+}
@@ -0,0 +1,132 @@
+package gb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracing 使用内存导出器初始化链路追踪，结束后恢复全局 TracerProvider，sampleRatio 默认为 1。
+func setupTestTracing(t *testing.T, sampleRatio ...float64) *tracetest.InMemoryExporter {
+	t.Helper()
+	previous := otel.GetTracerProvider()
+	exporter := tracetest.NewInMemoryExporter()
+	config := TracingConfig{ServiceName: "gb-test", SpanExporter: exporter}
+	if len(sampleRatio) > 0 {
+		config.SampleRatio = sampleRatio[0]
+	}
+	shutdown, err := InitTracing(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func TestTracingContinuesInboundTrace(t *testing.T) {
+	setupTestRedis(t)
+	exporter := setupTestTracing(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("traceparent")))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareTraceID())
+	var upstreamTraceparent string
+	engine.GET("/users/:id", func(c *gin.Context) {
+		ctx := GinContext(c)
+		InsRedis.Get(ctx, "user")
+		resp, err := R().SetContext(ctx).Get(upstream.URL)
+		if err != nil {
+			t.Error(err)
+		}
+		upstreamTraceparent = resp.String()
+		ResponseSuccess(c, nil)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Trace-Id"); got != traceID {
+		t.Fatalf("Trace-Id = %q, want inbound trace id %s", got, traceID)
+	}
+	if len(upstreamTraceparent) < 36 || upstreamTraceparent[3:35] != traceID {
+		t.Fatalf("upstream traceparent = %q, want trace id %s", upstreamTraceparent, traceID)
+	}
+
+	spans := exporter.GetSpans()
+	names := make(map[string]bool, len(spans))
+	for _, span := range spans {
+		names[span.Name] = true
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Fatalf("span %s has trace id %s, want %s", span.Name, span.SpanContext.TraceID(), traceID)
+		}
+	}
+	for _, want := range []string{"GET /users/:id", "redis.get", "HTTP GET"} {
+		if !names[want] {
+			t.Fatalf("missing span %q in %v", want, names)
+		}
+	}
+}
+
+func TestTracingLegacyTraceIDDoesNotForceSampling(t *testing.T) {
+	// TraceIDRatioBased 按 trace id 低 8 字节判断，全 f 在 0.5 采样率下不采样，全 0 时采样
+	const (
+		unsampledID = "4bf92f3577b34da6ffffffffffffffff"
+		sampledID   = "4bf92f3577b34da60000000000000001"
+	)
+	exporter := setupTestTracing(t, 0.5)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MiddlewareTraceID())
+	engine.GET("/ping", func(c *gin.Context) { ResponseSuccess(c, nil) })
+
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		exporter.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(TraceIDHeader, unsampledID)
+	if got := w.Header().Get(TraceIDHeader); got != unsampledID {
+		t.Fatalf("Trace-Id = %q, want legacy trace id %s", got, unsampledID)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 || !strings.HasSuffix(w.Header().Get("traceparent"), "-00") {
+		t.Fatalf("legacy Trace-Id forced sampling: %d spans, traceparent %q", len(spans), w.Header().Get("traceparent"))
+	}
+
+	w = serve(TraceIDHeader, sampledID)
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].SpanContext.TraceID().String() != sampledID {
+		t.Fatalf("legacy Trace-Id within the sample ratio exported %d spans", len(spans))
+	}
+
+	// 上游明确不采样时仍然跟随上游
+	serve("traceparent", "00-"+sampledID+"-00f067aa0ba902b7-00")
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Fatalf("unsampled traceparent exported %d spans", len(spans))
+	}
+}
+
+func TestInitTracingRequiresServiceName(t *testing.T) {
+	if _, err := InitTracing(TracingConfig{SpanExporter: tracetest.NewInMemoryExporter()}); err == nil {
+		t.Fatal("InitTracing without ServiceName succeeded")
+	}
+}
@@ -0,0 +1,240 @@
+package gb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjf2000/ants/v2"
+	"github.com/rs/xid"
+)
+
+// DefaultUploadAllowedTypes 默认允许上传的 MIME 类型（按文件内容识别）。
+var DefaultUploadAllowedTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+	"application/pdf",
+}
+
+type UploadConfig struct {
+	Storage      Storage    // 存储，必填
+	FormField    string     // 表单字段名，默认 "file"
+	Dir          string     // key 前缀，文件保存为 Dir/NowDateDirectory()/随机名.扩展名，扩展名由识别出的类型决定
+	MaxSize      int64      // 单个文件的最大字节数，默认 10MB
+	MaxFiles     int        // 单次上传的最大文件数，默认 10
+	AllowedTypes []string   // 允许的 MIME 类型，支持 "image/*" 通配，默认 DefaultUploadAllowedTypes
+	Pool         *ants.Pool // 上传使用的协程池，默认使用大小为 CPU 数 * 4 的共享协程池
+}
+
+// UploadedFile 上传结果。
+type UploadedFile struct {
+	Name        string `json:"name"` // 原始文件名
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag,omitempty"`
+}
+
+var (
+	uploadPool     *ants.Pool
+	uploadPoolOnce sync.Once
+)
+
+// defaultUploadPool 所有上传共享的有界协程池。
+func defaultUploadPool() *ants.Pool {
+	uploadPoolOnce.Do(func() {
+		pool, err := ants.NewPool(runtime.NumCPU() * 4)
+		if err != nil {
+			panic("创建上传协程池失败: " + err.Error())
+		}
+		uploadPool = pool
+		RegisterAntsPoolMetrics("upload", pool)
+	})
+	return uploadPool
+}
+
+// UploadFiles 校验并保存请求中 FormField 字段的文件，全部成功才返回结果，任一文件失败时删除已保存的文件。
+// 文件类型按内容识别（GetFileContentType），保存的扩展名也由识别结果决定，不信任客户端提交的 Content-Type 与文件名。
+func UploadFiles(c *gin.Context, config UploadConfig) ([]UploadedFile, error) {
+	if config.Storage == nil {
+		return nil, errors.New("UploadConfig.Storage为空")
+	}
+	if config.FormField == "" {
+		config.FormField = "file"
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 10 << 20
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = 10
+	}
+	if len(config.AllowedTypes) == 0 {
+		config.AllowedTypes = DefaultUploadAllowedTypes
+	}
+	if config.Pool == nil {
+		config.Pool = defaultUploadPool()
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxSize*int64(config.MaxFiles)+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrFileTooLarge
+		}
+		return nil, ErrInvalidParam.WithMessage("读取上传文件失败: %v", err)
+	}
+	files := form.File[config.FormField]
+	if len(files) == 0 {
+		return nil, ErrInvalidParam.WithMessage("缺少上传文件: %s", config.FormField)
+	}
+	if len(files) > config.MaxFiles {
+		return nil, ErrInvalidParam.WithMessage("单次最多上传%d个文件", config.MaxFiles)
+	}
+	for _, file := range files {
+		if file.Size > config.MaxSize {
+			return nil, ErrFileTooLarge.WithMessage("文件 %s 超过大小限制", file.Filename)
+		}
+	}
+
+	ctx := c.Request.Context()
+	results := make([]UploadedFile, len(files))
+	errs := make([]error, len(files))
+	var group sync.WaitGroup
+	for i, file := range files {
+		group.Add(1)
+		if err := config.Pool.Submit(func() {
+			defer group.Done()
+			results[i], errs[i] = uploadFile(ctx, config, file)
+		}); err != nil {
+			group.Done()
+			errs[i] = err
+		}
+	}
+	group.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		for i, result := range results {
+			if errs[i] == nil && result.Key != "" {
+				config.Storage.Delete(context.WithoutCancel(ctx), result.Key)
+			}
+		}
+		for _, e := range errs {
+			var appErr *AppError
+			if errors.As(e, &appErr) {
+				return nil, appErr
+			}
+		}
+		return nil, err
+	}
+	return results, nil
+}
+
+// UploadHandler 返回上传处理函数，成功时以 ResponseSuccess 返回 []UploadedFile。
+func UploadHandler(config UploadConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		files, err := UploadFiles(c, config)
+		if err != nil {
+			WriteGinWarnLog(c, "upload failed: %v", err)
+			ResponseError(c, err)
+			return
+		}
+		ResponseSuccess(c, files)
+	}
+}
+
+// uploadFile 识别文件类型并写入存储。
+func uploadFile(ctx context.Context, config UploadConfig, header *multipart.FileHeader) (UploadedFile, error) {
+	file, err := header.Open()
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return UploadedFile{}, err
+	}
+	contentType := GetFileContentType(head[:n])
+	if !MIMEAllowed(contentType, config.AllowedTypes) {
+		return UploadedFile{}, ErrFileTypeNotAllowed.WithMessage("不支持的文件类型: %s", header.Filename)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return UploadedFile{}, err
+	}
+
+	key := path.Join(config.Dir, NowDateDirectory(), xid.New().String()+uploadExt(contentType))
+	object, err := config.Storage.Put(ctx, key, file, header.Size, contentType)
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	return UploadedFile{
+		Name:        header.Filename,
+		Key:         object.Key,
+		Size:        header.Size,
+		ContentType: contentType,
+		ETag:        object.ETag,
+	}, nil
+}
+
+// MIMEAllowed 判断 contentType 是否在允许列表中，忽略 charset 等参数，支持 "image/*" 通配。
+func MIMEAllowed(contentType string, allowed []string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	for _, item := range allowed {
+		item = strings.ToLower(item)
+		if item == contentType || item == "*/*" ||
+			(strings.HasSuffix(item, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(item, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadExts 常见类型的扩展名，mime.ExtensionsByType 对部分类型返回多个扩展名且顺序不固定。
+var uploadExts = map[string]string{
+	"image/jpeg":       ".jpg",
+	"image/png":        ".png",
+	"image/gif":        ".gif",
+	"image/webp":       ".webp",
+	"image/bmp":        ".bmp",
+	"image/x-icon":     ".ico",
+	"application/pdf":  ".pdf",
+	"application/zip":  ".zip",
+	"application/gzip": ".gz",
+	"audio/mpeg":       ".mp3",
+	"audio/wave":       ".wav",
+	"video/mp4":        ".mp4",
+	"video/webm":       ".webm",
+	"text/plain":       ".txt",
+}
+
+// uploadExt 返回识别出的 MIME 类型对应的扩展名，未知类型不带扩展名。
+// 扩展名决定 LocalStorage 下载时的 Content-Type，不能取自客户端文件名，否则 PNG 内容命名为 x.html 会以 text/html 返回。
+func uploadExt(contentType string) string {
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	if ext, ok := uploadExts[contentType]; ok {
+		return ext
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		return ""
+	}
+	exts, _ := mime.ExtensionsByType(contentType)
+	if len(exts) == 0 {
+		return ""
+	}
+	sort.Strings(exts)
+	return exts[0]
+}
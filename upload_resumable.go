@@ -0,0 +1,614 @@
+package gb
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+)
+
+const (
+	TusVersion           = "1.0.0"
+	tusOffsetContentType = "application/offset+octet-stream"
+	// StatusChecksumMismatch tus checksum 扩展定义的状态码
+	StatusChecksumMismatch = 460
+)
+
+type ResumableUploadConfig struct {
+	Dir          string                                                      // 分片拼接使用的本地目录，必填
+	Prefix       string                                                      // redis key 前缀，默认 "gb:upload"
+	MaxSize      int64                                                       // 单个文件的最大字节数，默认 5GB
+	Expiry       time.Duration                                               // 上传会话的有效期，默认 24 小时
+	LockTTL      time.Duration                                               // 单次 PATCH 的锁超时时间，默认 10 分钟
+	AllowedTypes []string                                                    // 允许的 MIME 类型（按内容识别），为空时不限制
+	IdentityKey  string                                                      // JWT 身份键，会话绑定创建者，默认 IdentityKey；未认证时不校验
+	Storage      Storage                                                     // 上传完成后转存的存储，为空时文件保留在 Dir 中
+	StorageDir   string                                                      // 转存的 key 前缀，文件保存为 StorageDir/NowDateDirectory()/上传ID.扩展名，扩展名由识别出的类型决定
+	OnComplete   func(ctx context.Context, upload ResumableUploadInfo) error // 上传完成后调用，返回错误时客户端收到 500，会话保留可重试查询
+}
+
+// ResumableUploadInfo 上传会话信息。
+type ResumableUploadInfo struct {
+	ID          string            `json:"id"`
+	Length      int64             `json:"length"`
+	Offset      int64             `json:"offset"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	SHA256      string            `json:"sha256,omitempty"`
+	Completed   bool              `json:"completed"`
+	Key         string            `json:"key,omitempty"` // 转存到 Storage 后的 key
+	Path        string            `json:"-"`             // 本地文件路径，未配置 Storage 时为最终文件
+	Owner       string            `json:"-"`             // 创建者身份
+	ExpiresAt   time.Time         `json:"expires_at"`
+}
+
+// ResumableUploader tus 1.0.0 协议（creation、expiration、checksum、termination 扩展）的断点续传服务端。
+// 会话状态保存在 InsRedis，分片按偏移量追加到 Dir 下的本地文件。
+// 为兼容 tus 客户端，错误响应使用对应的 HTTP 状态码，响应体仍为 Response 格式。
+type ResumableUploader struct {
+	config ResumableUploadConfig
+}
+
+// NewResumableUploader 创建断点续传服务端。
+func NewResumableUploader(config ResumableUploadConfig) (*ResumableUploader, error) {
+	if config.Dir == "" {
+		return nil, errors.New("ResumableUploadConfig.Dir为空")
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if config.Prefix == "" {
+		config.Prefix = "gb:upload"
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = 5 << 30
+	}
+	if config.Expiry <= 0 {
+		config.Expiry = 24 * time.Hour
+	}
+	if config.LockTTL <= 0 {
+		config.LockTTL = 10 * time.Minute
+	}
+	return &ResumableUploader{config: config}, nil
+}
+
+// Register 在 relativePath 上注册 tus 端点：
+// OPTIONS 查询服务端能力，POST 创建会话，HEAD 查询偏移量，PATCH 上传分片，DELETE 取消上传，GET 以 JSON 返回进度。
+func (u *ResumableUploader) Register(group *gin.RouterGroup, relativePath string) {
+	relativePath = strings.TrimRight(relativePath, "/")
+	group.OPTIONS(relativePath, u.options)
+	group.POST(relativePath, u.create)
+	group.OPTIONS(relativePath+"/:id", u.options)
+	group.HEAD(relativePath+"/:id", u.head)
+	group.PATCH(relativePath+"/:id", u.patch)
+	group.DELETE(relativePath+"/:id", u.terminate)
+	group.GET(relativePath+"/:id", u.progress)
+	// 不支持 PATCH、DELETE 的客户端使用 POST + X-HTTP-Method-Override
+	group.POST(relativePath+"/:id", func(c *gin.Context) {
+		switch strings.ToUpper(c.GetHeader("X-HTTP-Method-Override")) {
+		case http.MethodPatch:
+			u.patch(c)
+		case http.MethodDelete:
+			u.terminate(c)
+		default:
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// Info 查询上传会话。
+func (u *ResumableUploader) Info(ctx context.Context, id string) (ResumableUploadInfo, error) {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return ResumableUploadInfo{}, redisClientNilErr()
+	}
+	values, err := InsRedis.HGetAll(ctx, u.key(id)).Result()
+	if err != nil {
+		return ResumableUploadInfo{}, err
+	}
+	if len(values) == 0 {
+		return ResumableUploadInfo{}, ErrObjectNotFound
+	}
+	info := ResumableUploadInfo{
+		ID:          id,
+		Metadata:    parseTusMetadata(values["metadata"]),
+		ContentType: values["content_type"],
+		SHA256:      values["sha256"],
+		Completed:   values["completed"] == "1",
+		Key:         values["key"],
+		Path:        u.filename(id),
+		Owner:       values["owner"],
+	}
+	info.Length, _ = strconv.ParseInt(values["length"], 10, 64)
+	info.Offset, _ = strconv.ParseInt(values["offset"], 10, 64)
+	expires, _ := strconv.ParseInt(values["expires"], 10, 64)
+	info.ExpiresAt = time.Unix(expires, 0)
+	return info, nil
+}
+
+// CleanExpired 删除会话已过期的本地文件，可配合定时任务调用。
+func (u *ResumableUploader) CleanExpired(ctx context.Context) (int, error) {
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		return 0, redisClientNilErr()
+	}
+	entries, err := os.ReadDir(u.config.Dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".part") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < u.config.Expiry {
+			continue
+		}
+		exists, err := InsRedis.Exists(ctx, u.key(strings.TrimSuffix(name, ".part"))).Result()
+		if err != nil {
+			return removed, err
+		}
+		if exists == 0 && os.Remove(filepath.Join(u.config.Dir, name)) == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// options 方法用于处理options相关逻辑。
+func (u *ResumableUploader) options(c *gin.Context) {
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Tus-Version", TusVersion)
+	c.Header("Tus-Extension", "creation,expiration,checksum,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(u.config.MaxSize, 10))
+	c.Header("Tus-Checksum-Algorithm", "sha1,md5,sha256")
+	c.Status(http.StatusNoContent)
+}
+
+// create 创建上传会话，返回 201 与 Location。
+func (u *ResumableUploader) create(c *gin.Context) {
+	if !u.checkVersion(c) {
+		return
+	}
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		tusError(c, http.StatusBadRequest, ErrInvalidParam.WithMessage("Upload-Length无效"))
+		return
+	}
+	if length > u.config.MaxSize {
+		tusError(c, http.StatusRequestEntityTooLarge, ErrFileTooLarge)
+		return
+	}
+	metadata := c.GetHeader("Upload-Metadata")
+	if metadata != "" && parseTusMetadata(metadata) == nil {
+		tusError(c, http.StatusBadRequest, ErrInvalidParam.WithMessage("Upload-Metadata无效"))
+		return
+	}
+
+	id := xid.New().String()
+	file, err := os.Create(u.filename(id))
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, ErrServerBusy)
+		return
+	}
+	file.Close()
+
+	expiresAt := time.Now().Add(u.config.Expiry)
+	state, _ := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	ctx := c.Request.Context()
+	pipe := InsRedis.TxPipeline()
+	pipe.HSet(ctx, u.key(id), map[string]any{
+		"length":   length,
+		"offset":   0,
+		"metadata": metadata,
+		"owner":    GetIdentity(c, u.config.IdentityKey),
+		"expires":  expiresAt.Unix(),
+		"hash":     base64.StdEncoding.EncodeToString(state),
+	})
+	pipe.ExpireAt(ctx, u.key(id), expiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		os.Remove(u.filename(id))
+		WriteGinWarnLog(c, "resumable upload create failed: %v", err)
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return
+	}
+
+	if length == 0 {
+		info, _ := u.Info(ctx, id)
+		info.SHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+		if status, appErr := u.complete(ctx, &info); appErr != nil {
+			tusError(c, status, appErr)
+			return
+		}
+	}
+
+	location := strings.TrimRight(c.Request.URL.Path, "/") + "/" + id
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Location", location)
+	c.Header("Upload-Expires", expiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// head 返回当前偏移量。
+func (u *ResumableUploader) head(c *gin.Context) {
+	if !u.checkVersion(c) {
+		return
+	}
+	info, ok := u.load(c)
+	if !ok {
+		return
+	}
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(info.Length, 10))
+	c.Header("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	if metadata := InsRedis.HGet(c.Request.Context(), u.key(info.ID), "metadata").Val(); metadata != "" {
+		c.Header("Upload-Metadata", metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+// progress 以 JSON 返回上传进度，供非 tus 客户端查询。
+func (u *ResumableUploader) progress(c *gin.Context) {
+	info, err := u.Info(c.Request.Context(), c.Param("id"))
+	if err != nil || !u.owned(c, info) {
+		ResponseError(c, ErrNotFound)
+		return
+	}
+	ResponseSuccess(c, info)
+}
+
+// terminate 取消上传并删除本地文件。
+func (u *ResumableUploader) terminate(c *gin.Context) {
+	if !u.checkVersion(c) {
+		return
+	}
+	info, ok := u.load(c)
+	if !ok {
+		return
+	}
+	if err := InsRedis.Del(c.Request.Context(), u.key(info.ID)).Err(); err != nil {
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return
+	}
+	os.Remove(info.Path)
+	c.Header("Tus-Resumable", TusVersion)
+	c.Status(http.StatusNoContent)
+}
+
+// patch 在 Upload-Offset 处追加分片，携带 Upload-Checksum 时校验分片，最后一个分片到达后校验整体 sha256。
+func (u *ResumableUploader) patch(c *gin.Context) {
+	if !u.checkVersion(c) {
+		return
+	}
+	if c.ContentType() != tusOffsetContentType {
+		tusError(c, http.StatusUnsupportedMediaType, ErrInvalidParam.WithMessage("Content-Type需为%s", tusOffsetContentType))
+		return
+	}
+	info, ok := u.load(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset != info.Offset {
+		c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		tusError(c, http.StatusConflict, ErrUploadOffsetMismatch)
+		return
+	}
+	chunkHash, expected, err := parseUploadChecksum(c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		tusError(c, http.StatusBadRequest, ErrInvalidParam.WithMessage("%v", err))
+		return
+	}
+
+	// 锁的值为随机 token，释放时比较后删除，避免锁超时后误删其他请求持有的锁
+	lockKey := u.key(info.ID) + ":lock"
+	token := xid.New().String()
+	locked, err := InsRedis.SetNX(ctx, lockKey, token, u.config.LockTTL).Result()
+	if err != nil {
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return
+	}
+	if !locked {
+		tusError(c, http.StatusLocked, ErrUploadLocked)
+		return
+	}
+	defer InsRedis.LuaRedisDistributedUnlock(lockKey, token)
+
+	// 加锁前读取的会话可能已被并发的 PATCH 更新，加锁后重新读取偏移量与 sha256 状态
+	info, err = u.Info(ctx, info.ID)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			tusError(c, http.StatusNotFound, ErrNotFound)
+		} else {
+			tusError(c, http.StatusInternalServerError, ErrRedis)
+		}
+		return
+	}
+	if offset != info.Offset {
+		c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		tusError(c, http.StatusConflict, ErrUploadOffsetMismatch)
+		return
+	}
+	if info.Completed || offset == info.Length {
+		c.Header("Tus-Resumable", TusVersion)
+		c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		c.Status(http.StatusNoContent)
+		return
+	}
+	state, err := base64.StdEncoding.DecodeString(InsRedis.HGet(ctx, u.key(info.ID), "hash").Val())
+	total := sha256.New()
+	if err != nil || total.(encoding.BinaryUnmarshaler).UnmarshalBinary(state) != nil {
+		tusError(c, http.StatusInternalServerError, ErrServerBusy.WithMessage("上传状态损坏"))
+		return
+	}
+
+	file, err := os.OpenFile(info.Path, os.O_WRONLY, 0o644)
+	if err != nil {
+		tusError(c, http.StatusNotFound, ErrNotFound)
+		return
+	}
+	defer file.Close()
+	// 丢弃上次中断时写入但未记录的数据
+	if err := file.Truncate(offset); err != nil {
+		tusError(c, http.StatusInternalServerError, ErrServerBusy)
+		return
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		tusError(c, http.StatusInternalServerError, ErrServerBusy)
+		return
+	}
+
+	remaining := info.Length - offset
+	writers := []io.Writer{file, total}
+	if chunkHash != nil {
+		writers = append(writers, chunkHash)
+	}
+	written, copyErr := io.Copy(io.MultiWriter(writers...), io.LimitReader(c.Request.Body, remaining+1))
+	if written > remaining {
+		file.Truncate(offset)
+		tusError(c, http.StatusRequestEntityTooLarge, ErrFileTooLarge.WithMessage("分片超出Upload-Length"))
+		return
+	}
+	if chunkHash != nil && (copyErr != nil || !bytes.Equal(chunkHash.Sum(nil), expected)) {
+		// 携带校验和时必须完整接收分片
+		file.Truncate(offset)
+		if copyErr != nil {
+			tusError(c, http.StatusBadRequest, ErrInvalidParam.WithMessage("读取分片失败"))
+			return
+		}
+		tusError(c, StatusChecksumMismatch, ErrUploadChecksumMismatch)
+		return
+	}
+	// 未携带校验和时保留已接收的数据，客户端可从新的偏移量继续
+	newOffset := offset + written
+
+	if len(u.config.AllowedTypes) > 0 && offset < 512 && (newOffset >= 512 || newOffset == info.Length) {
+		contentType, err := sniffFile(info.Path)
+		if err != nil || !MIMEAllowed(contentType, u.config.AllowedTypes) {
+			InsRedis.Del(ctx, u.key(info.ID))
+			file.Close()
+			os.Remove(info.Path)
+			tusError(c, http.StatusUnsupportedMediaType, ErrFileTypeNotAllowed)
+			return
+		}
+	}
+
+	state, _ = total.(encoding.BinaryMarshaler).MarshalBinary()
+	fields := map[string]any{
+		"offset": newOffset,
+		"hash":   base64.StdEncoding.EncodeToString(state),
+	}
+	if err := InsRedis.HSet(context.WithoutCancel(ctx), u.key(info.ID), fields).Err(); err != nil {
+		file.Truncate(offset)
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return
+	}
+	if copyErr != nil {
+		// 客户端已断开，无需响应
+		c.Abort()
+		return
+	}
+
+	if newOffset == info.Length {
+		file.Close()
+		info.Offset = newOffset
+		info.SHA256 = hex.EncodeToString(total.Sum(nil))
+		if status, appErr := u.complete(ctx, &info); appErr != nil {
+			tusError(c, status, appErr)
+			return
+		}
+	}
+
+	c.Header("Tus-Resumable", TusVersion)
+	c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	c.Header("Upload-Expires", info.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusNoContent)
+}
+
+// complete 校验整体 sha256（Upload-Metadata 中的 sha256，十六进制），识别类型并转存。
+func (u *ResumableUploader) complete(ctx context.Context, info *ResumableUploadInfo) (int, *AppError) {
+	if expected := strings.ToLower(info.Metadata["sha256"]); expected != "" && expected != info.SHA256 {
+		InsRedis.Del(ctx, u.key(info.ID))
+		os.Remove(info.Path)
+		return StatusChecksumMismatch, ErrUploadChecksumMismatch.WithMessage("文件sha256校验失败")
+	}
+	contentType, err := sniffFile(info.Path)
+	if err != nil {
+		return http.StatusInternalServerError, ErrServerBusy
+	}
+	info.ContentType = contentType
+
+	if u.config.Storage != nil {
+		file, err := os.Open(info.Path)
+		if err != nil {
+			return http.StatusInternalServerError, ErrServerBusy
+		}
+		key := path.Join(u.config.StorageDir, NowDateDirectory(), info.ID+uploadExt(contentType))
+		object, err := u.config.Storage.Put(ctx, key, file, info.Length, contentType)
+		file.Close()
+		if err != nil {
+			zlog.Error().Err(err).Str("upload_id", info.ID).Msg("resumable upload store failed")
+			return http.StatusInternalServerError, ErrServerBusy
+		}
+		info.Key = object.Key
+		os.Remove(info.Path)
+	}
+	info.Completed = true
+	if err := InsRedis.HSet(ctx, u.key(info.ID), map[string]any{
+		"completed":    1,
+		"sha256":       info.SHA256,
+		"content_type": contentType,
+		"key":          info.Key,
+	}).Err(); err != nil {
+		return http.StatusInternalServerError, ErrRedis
+	}
+	if u.config.OnComplete != nil {
+		if err := u.config.OnComplete(ctx, *info); err != nil {
+			zlog.Error().Err(err).Str("upload_id", info.ID).Msg("resumable upload complete hook failed")
+			return http.StatusInternalServerError, ConvertToAppError(err)
+		}
+	}
+	return 0, nil
+}
+
+// checkVersion 校验 Tus-Resumable 请求头。
+func (u *ResumableUploader) checkVersion(c *gin.Context) bool {
+	if c.GetHeader("Tus-Resumable") != TusVersion {
+		c.Header("Tus-Version", TusVersion)
+		tusError(c, http.StatusPreconditionFailed, ErrInvalidParam.WithMessage("不支持的Tus-Resumable版本"))
+		return false
+	}
+	if InsRedis == nil || InsRedis.UniversalClient == nil {
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return false
+	}
+	return true
+}
+
+// load 读取会话并校验创建者，失败时已写出响应。
+func (u *ResumableUploader) load(c *gin.Context) (ResumableUploadInfo, bool) {
+	info, err := u.Info(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, ErrObjectNotFound) || (err == nil && !u.owned(c, info)) {
+		tusError(c, http.StatusNotFound, ErrNotFound)
+		return info, false
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		tusError(c, http.StatusInternalServerError, ErrRedis)
+		return info, false
+	}
+	return info, true
+}
+
+// owned 方法用于处理owned相关逻辑。
+func (u *ResumableUploader) owned(c *gin.Context, info ResumableUploadInfo) bool {
+	return info.Owner == "" || info.Owner == GetIdentity(c, u.config.IdentityKey)
+}
+
+// key 方法用于处理key相关逻辑。
+func (u *ResumableUploader) key(id string) string {
+	return u.config.Prefix + ":" + id
+}
+
+// filename 上传 ID 由 xid 生成，这里仍过滤路径字符以防被构造的 ID 越出目录。
+func (u *ResumableUploader) filename(id string) string {
+	return filepath.Join(u.config.Dir, filepath.Base(filepath.Clean("/"+id))+".part")
+}
+
+// tusError 使用指定的 HTTP 状态码返回错误。
+func tusError(c *gin.Context, status int, appErr *AppError) {
+	c.Set("resp-status", appErr.Code)
+	c.Set("resp-msg", appErr.Message)
+	setTraceHeaders(c)
+	c.Header("Tus-Resumable", TusVersion)
+	if c.Request.Method == http.MethodHead {
+		c.AbortWithStatus(status)
+		return
+	}
+	c.AbortWithStatusJSON(status, &Response{Code: appErr.Code, Message: appErr.Message})
+}
+
+// parseTusMetadata 解析 "key base64value,key2 base64value2"，格式错误时返回 nil。
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil
+		}
+		var value []byte
+		if len(parts) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil
+			}
+			value = decoded
+		}
+		metadata[parts[0]] = string(value)
+	}
+	return metadata
+}
+
+// parseUploadChecksum 解析 "算法 base64摘要"，未携带时返回 nil。
+func parseUploadChecksum(header string) (hash.Hash, []byte, error) {
+	if header == "" {
+		return nil, nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, nil, errors.New("Upload-Checksum格式错误")
+	}
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, nil, errors.New("Upload-Checksum格式错误")
+	}
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		return sha1.New(), expected, nil
+	case "md5":
+		return md5.New(), expected, nil
+	case "sha256":
+		return sha256.New(), expected, nil
+	default:
+		return nil, nil, fmt.Errorf("不支持的校验算法: %s", algorithm)
+	}
+}
+
+// sniffFile 读取文件头识别类型。
+func sniffFile(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return GetFileContentType(head[:n]), nil
+}
@@ -0,0 +1,172 @@
+package gb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tusTestServer struct {
+	engine   *gin.Engine
+	uploader *ResumableUploader
+	storage  *LocalStorage
+}
+
+func newTusTestServer(t *testing.T) *tusTestServer {
+	t.Helper()
+	root := t.TempDir()
+	storage, err := NewLocalStorage(LocalStorageConfig{Root: filepath.Join(root, "store")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploader, err := NewResumableUploader(ResumableUploadConfig{
+		Dir:          filepath.Join(root, "parts"),
+		Storage:      storage,
+		StorageDir:   "video",
+		AllowedTypes: []string{"image/*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	uploader.Register(&engine.RouterGroup, "/files")
+	return &tusTestServer{engine: engine, uploader: uploader, storage: storage}
+}
+
+func (s *tusTestServer) do(method, target string, body []byte, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("Tus-Resumable", TusVersion)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w
+}
+
+func (s *tusTestServer) patch(location string, offset int, chunk []byte) *httptest.ResponseRecorder {
+	return s.do(http.MethodPatch, location, chunk, map[string]string{
+		"Content-Type":  tusOffsetContentType,
+		"Upload-Offset": strconv.Itoa(offset),
+	})
+}
+
+func (s *tusTestServer) create(t *testing.T, data []byte, filename string) string {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte(filename)) +
+		",sha256 " + base64.StdEncoding.EncodeToString([]byte(hex.EncodeToString(sum[:])))
+	w := s.do(http.MethodPost, "/files", nil, map[string]string{
+		"Upload-Length":   strconv.Itoa(len(data)),
+		"Upload-Metadata": metadata,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", w.Code, w.Body.String())
+	}
+	return w.Header().Get("Location")
+}
+
+func TestResumableUploadFlow(t *testing.T) {
+	setupTestRedis(t)
+	s := newTusTestServer(t)
+	data := append(append([]byte{}, testPNG...), bytes.Repeat([]byte("abcdefgh"), 200)...)
+	location := s.create(t, data, "x.html")
+
+	if w := s.patch(location, 0, data[:700]); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "700" {
+		t.Fatalf("first patch status = %d, offset = %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	// 重放同一偏移量的分片
+	if w := s.patch(location, 0, data[:700]); w.Code != http.StatusConflict {
+		t.Fatalf("replayed patch status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := s.patch(location, 700, data[700:]); w.Code != http.StatusNoContent {
+		t.Fatalf("final patch status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	info, err := s.uploader.Info(context.Background(), location[strings.LastIndex(location, "/")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if !info.Completed || info.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("info = %+v", info)
+	}
+	if !strings.HasSuffix(info.Key, ".png") {
+		t.Fatalf("key = %q, want .png extension from sniffed type", info.Key)
+	}
+	object, err := s.storage.Stat(context.Background(), info.Key)
+	if err != nil || object.ContentType != "image/png" || object.Size != int64(len(data)) {
+		t.Fatalf("stored object = %+v, err = %v", object, err)
+	}
+}
+
+func TestResumableUploadLockOwnership(t *testing.T) {
+	m := setupTestRedis(t)
+	s := newTusTestServer(t)
+	data := append(append([]byte{}, testPNG...), bytes.Repeat([]byte("abcdefgh"), 200)...)
+	location := s.create(t, data, "a.png")
+	id := location[strings.LastIndex(location, "/")+1:]
+	if w := s.patch(location, 0, data[:700]); w.Code != http.StatusNoContent {
+		t.Fatalf("first patch status = %d", w.Code)
+	}
+
+	// 模拟另一个请求持有锁：锁被占用时返回 423，且不能删除他人的锁
+	lockKey := s.uploader.key(id) + ":lock"
+	m.Set(lockKey, "other")
+	if w := s.patch(location, 700, data[700:]); w.Code != http.StatusLocked {
+		t.Fatalf("locked patch status = %d, want %d", w.Code, http.StatusLocked)
+	}
+	if got, _ := m.Get(lockKey); got != "other" {
+		t.Fatalf("lock = %q, another request's lock was released", got)
+	}
+	m.Del(lockKey)
+
+	if w := s.patch(location, 700, data[700:]); w.Code != http.StatusNoContent {
+		t.Fatalf("final patch status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if m.Exists(lockKey) {
+		t.Fatal("lock was not released")
+	}
+}
+
+func TestResumableUploadRejectsDisallowedType(t *testing.T) {
+	setupTestRedis(t)
+	s := newTusTestServer(t)
+	data := []byte("<html><script>alert(1)</script></html>")
+	location := s.create(t, data, "a.png")
+	if w := s.patch(location, 0, data); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("patch status = %d, want %d", w.Code, http.StatusUnsupportedMediaType)
+	}
+	if w := s.do(http.MethodHead, location, nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("head status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestResumableUploadCleanExpiredWithoutRedis(t *testing.T) {
+	uploader, err := NewResumableUploader(ResumableUploadConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(uploader.config.Dir, "x.part")
+	if err := os.WriteFile(name, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(name, old, old)
+	if _, err := uploader.CleanExpired(context.Background()); err == nil {
+		t.Fatal("CleanExpired without redis should return an error")
+	}
+}
@@ -0,0 +1,74 @@
+package gb
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+func TestUploadExtFromSniffedType(t *testing.T) {
+	storage, err := NewLocalStorage(LocalStorageConfig{Root: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/upload", UploadHandler(UploadConfig{Storage: storage, Dir: "avatar"}))
+	engine.GET("/files/*key", func(c *gin.Context) {
+		ResponseStorageFile(c, storage, c.Param("key"), "", "inline")
+	})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "x.html")
+	part.Write(testPNG)
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var resp struct {
+		Code int            `json:"code"`
+		Data []UploadedFile `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Data) != 1 {
+		t.Fatalf("unexpected upload response: %s", w.Body.String())
+	}
+	key := resp.Data[0].Key
+	if !strings.HasSuffix(key, ".png") {
+		t.Fatalf("key = %q, want .png extension", key)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/"+key, nil))
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Fatalf("Content-Type = %q, want image/png", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+}
+
+func TestUploadExt(t *testing.T) {
+	cases := map[string]string{
+		"image/jpeg":                ".jpg",
+		"image/png":                 ".png",
+		"text/plain; charset=utf-8": ".txt",
+		"application/octet-stream":  "",
+		"":                          "",
+	}
+	for contentType, want := range cases {
+		if got := uploadExt(contentType); got != want {
+			t.Errorf("uploadExt(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+package gb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/xid"
+)
+
+type WSHubConfig struct {
+	JWT            *GinJWTMiddleware                      // 认证中间件，使用 ParseTokenString 校验 token，为空时不认证
+	TokenQuery     string                                 // 从查询参数读取 token（浏览器无法设置请求头），默认 "token"，同时支持 Authorization 请求头
+	Channel        string                                 // redis pub/sub 频道，默认 "gb:ws"；InsRedis 为空时仅在本实例内投递
+	PingInterval   time.Duration                          // ping 间隔，默认 30 秒
+	PongWait       time.Duration                          // 等待 pong 的超时时间，默认 60 秒
+	WriteWait      time.Duration                          // 单次写超时，默认 10 秒
+	MaxMessageSize int64                                  // 客户端消息的最大字节数，默认 64KB
+	SendBuffer     int                                    // 每个连接的发送队列长度，队列满时断开该连接，默认 256
+	CheckOrigin    func(r *http.Request) bool             // 校验 Origin，默认仅允许同源
+	OnConnect      func(client *WSClient)                 // 连接建立后调用
+	OnMessage      func(client *WSClient, message []byte) // 收到客户端消息时调用
+	OnDisconnect   func(client *WSClient)                 // 连接断开后调用
+}
+
+// WSHub 管理本实例的 WebSocket 连接，按用户与房间索引；配置 redis 后消息经 pub/sub 投递到所有实例。
+type WSHub struct {
+	config   WSHubConfig
+	upgrader websocket.Upgrader
+	nodeID   string
+
+	mu      sync.RWMutex
+	clients map[*WSClient]struct{}
+	users   map[string]map[*WSClient]struct{}
+	rooms   map[string]map[*WSClient]struct{}
+
+	pubsub *redis.PubSub
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+// WSClient 一个 WebSocket 连接。
+type WSClient struct {
+	ID     string
+	UserID string
+	Claims MapClaims
+
+	hub   *WSHub
+	conn  *websocket.Conn
+	send  chan []byte
+	done  chan struct{}
+	once  sync.Once
+	mu    sync.Mutex
+	rooms map[string]struct{}
+	keys  map[string]any
+}
+
+type wsTarget string
+
+const (
+	wsTargetAll  wsTarget = "all"
+	wsTargetUser wsTarget = "user"
+	wsTargetRoom wsTarget = "room"
+)
+
+// wsEnvelope redis 中传递的消息。
+type wsEnvelope struct {
+	Target  wsTarget `json:"target"`
+	Key     string   `json:"key,omitempty"`
+	Payload []byte   `json:"payload"`
+	Origin  string   `json:"origin"`
+}
+
+// NewWSHub 创建 WebSocket hub，InsRedis 已初始化时订阅 Channel 以接收其他实例发布的消息。
+func NewWSHub(config WSHubConfig) (*WSHub, error) {
+	if config.TokenQuery == "" {
+		config.TokenQuery = "token"
+	}
+	if config.Channel == "" {
+		config.Channel = "gb:ws"
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = 30 * time.Second
+	}
+	if config.PongWait <= config.PingInterval {
+		config.PongWait = config.PingInterval * 2
+	}
+	if config.WriteWait <= 0 {
+		config.WriteWait = 10 * time.Second
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 64 << 10
+	}
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = 256
+	}
+
+	h := &WSHub{
+		config:  config,
+		nodeID:  xid.New().String(),
+		clients: make(map[*WSClient]struct{}),
+		users:   make(map[string]map[*WSClient]struct{}),
+		rooms:   make(map[string]map[*WSClient]struct{}),
+		closed:  make(chan struct{}),
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     config.CheckOrigin,
+	}
+
+	if InsRedis != nil && InsRedis.UniversalClient != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		pubsub := InsRedis.Subscribe(ctx, config.Channel)
+		if _, err := pubsub.Receive(ctx); err != nil {
+			cancel()
+			pubsub.Close()
+			return nil, err
+		}
+		h.pubsub, h.cancel = pubsub, cancel
+		go h.subscribe(pubsub.Channel())
+	}
+	return h, nil
+}
+
+// Handler 返回升级为 WebSocket 的处理函数，认证失败时返回 ErrUnauthorized，不进行升级。
+func (h *WSHub) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-h.closed:
+			ResponseError(c, ErrServerBusy)
+			return
+		default:
+		}
+
+		client := &WSClient{
+			ID:    xid.New().String(),
+			hub:   h,
+			send:  make(chan []byte, h.config.SendBuffer),
+			done:  make(chan struct{}),
+			rooms: make(map[string]struct{}),
+		}
+		if h.config.JWT != nil {
+			claims, identity, err := h.authenticate(c)
+			if err != nil {
+				ResponseError(c, err)
+				c.Abort()
+				return
+			}
+			client.Claims, client.UserID = claims, identity
+		}
+
+		conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// Upgrade 已写出 400 响应
+			WriteGinWarnLog(c, "websocket upgrade failed: %v", err)
+			c.Abort()
+			return
+		}
+		client.conn = conn
+		h.register(client)
+		if h.config.OnConnect != nil {
+			h.config.OnConnect(client)
+		}
+
+		go client.writePump()
+		client.readPump()
+	}
+}
+
+// authenticate 从查询参数或 Authorization 请求头读取 token 并校验。
+func (h *WSHub) authenticate(c *gin.Context) (MapClaims, string, error) {
+	mw := h.config.JWT
+	tokenString := c.Query(h.config.TokenQuery)
+	if tokenString == "" {
+		auth := c.GetHeader("Authorization")
+		if mw.TokenHeadName != "" {
+			auth = strings.TrimPrefix(auth, mw.TokenHeadName+" ")
+		}
+		tokenString = strings.TrimSpace(auth)
+	}
+	if tokenString == "" {
+		return nil, "", ErrUnauthorized
+	}
+	token, err := mw.ParseTokenString(tokenString)
+	if err != nil || !token.Valid {
+		return nil, "", ErrUnauthorized
+	}
+	claims := ExtractClaimsFromToken(token)
+	if _, ok := claims["exp"]; !ok {
+		return nil, "", ErrUnauthorized
+	}
+
+	c.Set("JWT_PAYLOAD", claims)
+	c.Set("JWT_TOKEN", tokenString)
+	var identity any
+	if mw.IdentityHandler != nil {
+		identity = mw.IdentityHandler(c)
+	} else {
+		identity = claims[mw.IdentityKey]
+	}
+	if identity != nil {
+		c.Set(mw.IdentityKey, identity)
+	}
+	if mw.Authorizator != nil && !mw.Authorizator(identity, c) {
+		return nil, "", ErrForbiddenAuth
+	}
+	if identity == nil {
+		return claims, "", nil
+	}
+	return claims, fmt.Sprint(identity), nil
+}
+
+// SendToUser 向用户的所有连接发送消息，包括连接在其他实例上的。
+func (h *WSHub) SendToUser(ctx context.Context, userID string, data any) error {
+	return h.publish(ctx, wsTargetUser, userID, data)
+}
+
+// SendToRoom 向房间内的所有连接发送消息。
+func (h *WSHub) SendToRoom(ctx context.Context, room string, data any) error {
+	return h.publish(ctx, wsTargetRoom, room, data)
+}
+
+// Broadcast 向所有连接发送消息。
+func (h *WSHub) Broadcast(ctx context.Context, data any) error {
+	return h.publish(ctx, wsTargetAll, "", data)
+}
+
+// Online 判断用户是否在本实例上有连接。
+func (h *WSHub) Online(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.users[userID]) > 0
+}
+
+// Count 返回本实例的连接数。
+func (h *WSHub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Close 断开本实例的所有连接并取消订阅。
+func (h *WSHub) Close() error {
+	select {
+	case <-h.closed:
+		return nil
+	default:
+		close(h.closed)
+	}
+	if h.cancel != nil {
+		h.cancel()
+		h.pubsub.Close()
+	}
+	h.mu.RLock()
+	clients := make([]*WSClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+	for _, client := range clients {
+		client.Close()
+	}
+	return nil
+}
+
+// publish 配置 redis 时发布到频道，由各实例（包括本实例）在订阅中投递；否则直接在本实例投递。
+func (h *WSHub) publish(ctx context.Context, target wsTarget, key string, data any) error {
+	payload, err := wsPayload(data)
+	if err != nil {
+		return err
+	}
+	if h.pubsub == nil {
+		h.deliver(target, key, payload)
+		return nil
+	}
+	envelope, err := json.Marshal(wsEnvelope{Target: target, Key: key, Payload: payload, Origin: h.nodeID})
+	if err != nil {
+		return err
+	}
+	return InsRedis.Publish(ctx, h.config.Channel, envelope).Err()
+}
+
+// subscribe 方法用于处理subscribe相关逻辑。
+func (h *WSHub) subscribe(messages <-chan *redis.Message) {
+	for message := range messages {
+		var envelope wsEnvelope
+		if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+			zlog.Warn().Err(err).Msg("websocket pubsub message invalid")
+			continue
+		}
+		h.deliver(envelope.Target, envelope.Key, envelope.Payload)
+	}
+}
+
+// deliver 投递到本实例的连接。
+func (h *WSHub) deliver(target wsTarget, key string, payload []byte) {
+	h.mu.RLock()
+	var clients []*WSClient
+	switch target {
+	case wsTargetAll:
+		clients = make([]*WSClient, 0, len(h.clients))
+		for client := range h.clients {
+			clients = append(clients, client)
+		}
+	case wsTargetUser:
+		for client := range h.users[key] {
+			clients = append(clients, client)
+		}
+	case wsTargetRoom:
+		for client := range h.rooms[key] {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.RUnlock()
+	for _, client := range clients {
+		client.enqueue(payload)
+	}
+}
+
+// register 方法用于处理register相关逻辑。
+func (h *WSHub) register(client *WSClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+	if client.UserID != "" {
+		if h.users[client.UserID] == nil {
+			h.users[client.UserID] = make(map[*WSClient]struct{})
+		}
+		h.users[client.UserID][client] = struct{}{}
+	}
+}
+
+// unregister 方法用于处理unregister相关逻辑。
+func (h *WSHub) unregister(client *WSClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, client)
+	if users := h.users[client.UserID]; users != nil {
+		delete(users, client)
+		if len(users) == 0 {
+			delete(h.users, client.UserID)
+		}
+	}
+	client.mu.Lock()
+	for room := range client.rooms {
+		h.removeFromRoom(client, room)
+	}
+	client.mu.Unlock()
+}
+
+// removeFromRoom 调用方需持有 h.mu。
+func (h *WSHub) removeFromRoom(client *WSClient, room string) {
+	if members := h.rooms[room]; members != nil {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Join 加入房间。
+func (c *WSClient) Join(room string) {
+	h := c.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*WSClient]struct{})
+	}
+	h.rooms[room][c] = struct{}{}
+	c.mu.Lock()
+	c.rooms[room] = struct{}{}
+	c.mu.Unlock()
+}
+
+// Leave 离开房间。
+func (c *WSClient) Leave(room string) {
+	h := c.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeFromRoom(c, room)
+	c.mu.Lock()
+	delete(c.rooms, room)
+	c.mu.Unlock()
+}
+
+// Rooms 返回已加入的房间。
+func (c *WSClient) Rooms() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Send 仅向当前连接发送消息。
+func (c *WSClient) Send(data any) error {
+	payload, err := wsPayload(data)
+	if err != nil {
+		return err
+	}
+	if !c.enqueue(payload) {
+		return errors.New("websocket连接已关闭")
+	}
+	return nil
+}
+
+// Set 保存连接级别的数据。
+func (c *WSClient) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		c.keys = make(map[string]any)
+	}
+	c.keys[key] = value
+}
+
+// Get 读取连接级别的数据。
+func (c *WSClient) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.keys[key]
+	return value, ok
+}
+
+// Done 连接关闭后关闭。
+func (c *WSClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close 关闭连接。
+func (c *WSClient) Close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		c.conn.Close()
+	})
+}
+
+// enqueue 发送队列已满时视为慢连接并断开。
+func (c *WSClient) enqueue(payload []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		zlog.Warn().Str("client", c.ID).Str("user", c.UserID).Msg("websocket send buffer full, closing connection")
+		c.Close()
+		return false
+	}
+}
+
+// readPump 读取客户端消息，直到连接断开或 pong 超时。
+func (c *WSClient) readPump() {
+	h := c.hub
+	defer func() {
+		c.Close()
+		h.unregister(c)
+		if h.config.OnDisconnect != nil {
+			h.config.OnDisconnect(c)
+		}
+	}()
+	c.conn.SetReadLimit(h.config.MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	})
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if h.config.OnMessage != nil {
+			h.config.OnMessage(c, message)
+		}
+	}
+}
+
+// writePump 写出队列中的消息并定时发送 ping。
+func (c *WSClient) writePump() {
+	h := c.hub
+	ticker := time.NewTicker(h.config.PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(h.config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.config.WriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsPayload string、[]byte 原样发送，其他类型序列化为 JSON。
+func wsPayload(data any) ([]byte, error) {
+	switch v := data.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
+}
@@ -0,0 +1,129 @@
+package gb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// newWSTestNode 启动一个挂载 WSHub 的实例，模拟多实例部署中的一个节点。
+func newWSTestNode(t *testing.T, mw *GinJWTMiddleware) (*WSHub, string) {
+	t.Helper()
+	hub, err := NewWSHub(WSHubConfig{
+		JWT:       mw,
+		OnConnect: func(c *WSClient) { c.Join("lobby") },
+		OnMessage: func(c *WSClient, msg []byte) { _ = c.Send("echo:" + string(msg)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ws", hub.Handler())
+	server := httptest.NewServer(engine)
+	t.Cleanup(func() {
+		_ = hub.Close()
+		server.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func newWSTestJWT(t *testing.T) *GinJWTMiddleware {
+	t.Helper()
+	mw, err := InitGinJWTMiddleware(&GinJWTMiddleware{
+		Key:         []byte("ws-test-key"),
+		Timeout:     time.Hour,
+		PayloadFunc: func(data interface{}) MapClaims { return MapClaims{"identity": data} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw
+}
+
+func dialWS(t *testing.T, mw *GinJWTMiddleware, hub *WSHub, url, user string) *websocket.Conn {
+	t.Helper()
+	token, _, err := mw.TokenGenerator(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	deadline := time.Now().Add(time.Second)
+	for !hub.Online(user) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not register on the hub", user)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) string {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(msg)
+}
+
+func TestWSHubFanOutAcrossNodes(t *testing.T) {
+	setupTestRedis(t)
+	mw := newWSTestJWT(t)
+	hubA, urlA := newWSTestNode(t, mw)
+	hubB, urlB := newWSTestNode(t, mw)
+
+	alice := dialWS(t, mw, hubA, urlA, "alice")
+	bob := dialWS(t, mw, hubB, urlB, "bob")
+	if hubA.Online("bob") {
+		t.Fatal("bob is connected to node B, node A should not list him as local")
+	}
+
+	ctx := context.Background()
+	if err := hubA.SendToUser(ctx, "bob", "hi bob"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readWS(t, bob); got != "hi bob" {
+		t.Fatalf("bob got %s", got)
+	}
+
+	if err := hubB.SendToRoom(ctx, "lobby", "room"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readWS(t, alice); got != "room" {
+		t.Fatalf("alice got %s from room fan-out", got)
+	}
+	if got := readWS(t, bob); got != "room" {
+		t.Fatalf("bob got %s from room fan-out", got)
+	}
+
+	if err := alice.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readWS(t, alice); got != "echo:ping" {
+		t.Fatalf("alice got %s, want echo", got)
+	}
+}
+
+func TestWSHubRejectsMissingToken(t *testing.T) {
+	mw := newWSTestJWT(t)
+	_, url := newWSTestNode(t, mw)
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("dial without token succeeded")
+	}
+	if resp == nil || resp.StatusCode == http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected handshake response: %v", resp)
+	}
+}